@@ -56,6 +56,14 @@ func DoWithRetry(ctx context.Context, c Doer, req *http.Request, maxAttempts int
 	var resp *http.Response
 	var err error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		// Rewind bodies (JSON POST/DELETE) consumed by the previous attempt.
+		if attempt > 1 && req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
 		resp, err = c.Do(req)
 		if err == nil && resp.StatusCode < 500 && resp.StatusCode != 429 {
 			return resp, nil
@@ -12,12 +12,13 @@ import (
 	"strings"
 
 	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/tokensource"
 )
 
 // Client handles direct Port entity upserts when webhooks aren't used.
+// Client-credentials tokens are refreshed before they expire.
 type Client struct {
 	base   string
-	token  string
 	client httpx.Doer
 }
 
@@ -26,42 +27,55 @@ func NewClient(ctx context.Context, hc httpx.Doer, region, accessToken, clientID
 	if base == "" {
 		base = "https://api.getport.io"
 	}
-	tok := strings.TrimSpace(accessToken)
-	if tok == "" {
-		ep := base + "/v1/auth/access_token"
-		body := map[string]string{"clientId": clientID, "clientSecret": clientSecret}
+	var src *tokensource.Source
+	if tok := strings.TrimSpace(accessToken); tok != "" {
+		src = tokensource.Static(tok)
+	} else {
 		if clientID == "" || clientSecret == "" {
 			return nil, errors.New("PORT_CLIENT_ID/PORT_CLIENT_SECRET or PORT_ACCESS_TOKEN required")
 		}
-		b, _ := json.Marshal(body)
-		req, _ := http.NewRequestWithContext(ctx, "POST", ep, bytes.NewReader(b))
-		req.Header.Set("Content-Type", "application/json")
-		httpx.SetUserAgent(req)
-		resp, err := httpx.DoWithRetry(ctx, hc, req, 3)
-		if err != nil {
-			return nil, fmt.Errorf("port auth: %w", err)
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode >= 300 {
-			all, _ := io.ReadAll(resp.Body)
-			return nil, fmt.Errorf("port auth failed: %s %s", resp.Status, string(all))
-		}
-		var out struct {
-			AccessToken string `json:"accessToken"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		src = tokensource.New(func(ctx context.Context) (tokensource.Token, error) {
+			return accessTokenFor(ctx, hc, base, clientID, clientSecret)
+		})
+		// Fail fast on bad credentials rather than on the first upsert.
+		if _, err := src.Token(ctx); err != nil {
 			return nil, err
 		}
-		tok = out.AccessToken
 	}
-	return &Client{base: base, token: tok, client: hc}, nil
+	u, _ := url.Parse(base)
+	return &Client{base: base, client: tokensource.NewDoer(hc, src, u.Host)}, nil
+}
+
+func accessTokenFor(ctx context.Context, hc httpx.Doer, base, clientID, clientSecret string) (tokensource.Token, error) {
+	ep := base + "/v1/auth/access_token"
+	body := map[string]string{"clientId": clientID, "clientSecret": clientSecret}
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequestWithContext(ctx, "POST", ep, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	httpx.SetUserAgent(req)
+	resp, err := httpx.DoWithRetry(ctx, hc, req, 3)
+	if err != nil {
+		return tokensource.Token{}, fmt.Errorf("port auth: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return tokensource.Token{}, fmt.Errorf("port auth failed: %s %s", resp.Status, string(all))
+	}
+	var out struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresIn   int64  `json:"expiresIn"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return tokensource.Token{}, err
+	}
+	return tokensource.Token{Value: out.AccessToken, Expiry: tokensource.ExpiresIn(out.ExpiresIn)}, nil
 }
 
 func (p *Client) UpsertEntity(ctx context.Context, blueprint string, entity any) error {
 	ep := fmt.Sprintf("%s/v1/blueprints/%s/entities?upsert=true&merge=true", p.base, url.PathEscape(blueprint))
 	b, _ := json.Marshal(entity)
 	req, _ := http.NewRequestWithContext(ctx, "POST", ep, bytes.NewReader(b))
-	req.Header.Set("Authorization", "Bearer "+p.token)
 	req.Header.Set("Content-Type", "application/json")
 	httpx.SetUserAgent(req)
 	resp, err := httpx.DoWithRetry(ctx, p.client, req, 3)
@@ -75,3 +89,77 @@ func (p *Client) UpsertEntity(ctx context.Context, blueprint string, entity any)
 	}
 	return nil
 }
+
+// MaxBulkEntities is the most entities Port accepts per bulk upsert.
+const MaxBulkEntities = 20
+
+// BulkUpsertEntities upserts entities in chunks of MaxBulkEntities. Port
+// answers 207 with per-entity errors; those are returned joined.
+func (p *Client) BulkUpsertEntities(ctx context.Context, blueprint string, entities []any) error {
+	ep := fmt.Sprintf("%s/v1/blueprints/%s/entities/bulk?upsert=true&merge=true", p.base, url.PathEscape(blueprint))
+	var errs []error
+	for start := 0; start < len(entities); start += MaxBulkEntities {
+		chunk := entities[start:min(start+MaxBulkEntities, len(entities))]
+		b, _ := json.Marshal(map[string]any{"entities": chunk})
+		req, _ := http.NewRequestWithContext(ctx, "POST", ep, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		httpx.SetUserAgent(req)
+		resp, err := httpx.DoWithRetry(ctx, p.client, req, 3)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if resp.StatusCode >= 300 && resp.StatusCode != http.StatusMultiStatus {
+			all, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			errs = append(errs, fmt.Errorf("port bulk upsert failed: %s %s", resp.Status, all))
+			continue
+		}
+		var out struct {
+			Errors []struct {
+				Identifier string `json:"identifier"`
+				Message    string `json:"message"`
+			} `json:"errors"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&out)
+		_ = resp.Body.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("port bulk upsert: %w", err))
+			continue
+		}
+		for _, e := range out.Errors {
+			errs = append(errs, fmt.Errorf("port bulk upsert %s: %s", e.Identifier, e.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Entity is the subset of a Port entity read back by the worker.
+type Entity struct {
+	Identifier string         `json:"identifier"`
+	Properties map[string]any `json:"properties"`
+	Relations  map[string]any `json:"relations"`
+}
+
+// ListEntities returns every entity of a blueprint.
+func (p *Client) ListEntities(ctx context.Context, blueprint string) ([]Entity, error) {
+	ep := fmt.Sprintf("%s/v1/blueprints/%s/entities?exclude_calculated_properties=true", p.base, url.PathEscape(blueprint))
+	req, _ := http.NewRequestWithContext(ctx, "GET", ep, nil)
+	httpx.SetUserAgent(req)
+	resp, err := httpx.DoWithRetry(ctx, p.client, req, 3)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("port list entities failed: %s %s", resp.Status, all)
+	}
+	var out struct {
+		Entities []Entity `json:"entities"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out.Entities, nil
+}
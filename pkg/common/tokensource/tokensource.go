@@ -0,0 +1,142 @@
+// Package tokensource caches short-lived bearer tokens (OAuth2 client
+// credentials, GitHub App installation tokens) and refreshes them before
+// they expire, so long runs outlive a single token.
+package tokensource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// RefreshSkew is how long before expiry a cached token is replaced.
+const RefreshSkew = 5 * time.Minute
+
+// Token is an access token and when it stops being valid; a zero Expiry
+// never expires.
+type Token struct {
+	Value  string
+	Expiry time.Time
+}
+
+// ExpiresIn converts an OAuth2 expires_in (seconds) into an expiry time;
+// zero or negative means unknown and yields a token that never expires.
+func ExpiresIn(seconds int64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(seconds) * time.Second)
+}
+
+// FetchFunc mints a new token.
+type FetchFunc func(ctx context.Context) (Token, error)
+
+// Source hands out a cached token, fetching a new one when none is cached
+// or the cached one expires within RefreshSkew. It is safe for concurrent use.
+type Source struct {
+	fetch FetchFunc
+
+	mu  sync.Mutex
+	tok Token
+}
+
+// New returns a Source backed by fetch.
+func New(fetch FetchFunc) *Source {
+	return &Source{fetch: fetch}
+}
+
+// Static returns a Source that always hands out tok (e.g. a pre-issued
+// access token); it cannot be refreshed.
+func Static(tok string) *Source {
+	return New(func(context.Context) (Token, error) { return Token{Value: tok}, nil })
+}
+
+// Token returns a valid token, refreshing it if needed.
+func (s *Source) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok.Value != "" && (s.tok.Expiry.IsZero() || time.Until(s.tok.Expiry) > RefreshSkew) {
+		return s.tok.Value, nil
+	}
+	t, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.tok = t
+	return t.Value, nil
+}
+
+// Invalidate drops the cached token if it is still stale, so concurrent
+// callers rejected with the same token trigger a single refresh.
+func (s *Source) Invalidate(stale string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok.Value == stale {
+		s.tok = Token{}
+	}
+}
+
+// Do sends req with a bearer token from src. On 401 it drops that token and
+// retries once with a fresh one; bodies are replayed through req.GetBody.
+func Do(next httpx.Doer, src *Source, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	tok, err := src.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.Do(withBearer(req, tok))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	src.Invalidate(tok)
+	fresh, err := src.Token(ctx)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("refresh token after 401: %w", err)
+	}
+	if fresh == tok {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+	r := withBearer(req, fresh)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return next.Do(r)
+}
+
+// NewDoer wraps next so requests to host without an Authorization header
+// are sent through Do with src. Other requests pass through untouched.
+func NewDoer(next httpx.Doer, src *Source, host string) httpx.Doer {
+	return &doer{next: next, src: src, host: host}
+}
+
+type doer struct {
+	next httpx.Doer
+	src  *Source
+	host string
+}
+
+func (d *doer) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != d.host || req.Header.Get("Authorization") != "" {
+		return d.next.Do(req)
+	}
+	return Do(d.next, d.src, req)
+}
+
+func withBearer(req *http.Request, tok string) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+tok)
+	return r
+}
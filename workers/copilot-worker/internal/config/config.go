@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config holds runtime configuration populated from environment variables.
@@ -18,14 +19,44 @@ type Config struct {
 	WebhookSecret    string
 
 	WebhookSeatsURL   string
+	WebhookUsageURL   string
 	WebhookM365SumURL string
 	WebhookM365UsrURL string
 
 	// GitHub
-	GitHubOrg     string
+	// GitHubOrgs comes from GITHUB_ORGS (or the single GITHUB_ORG).
+	GitHubOrgs    []GitHubOrg
 	GitHubToken   string
 	GitHubAPIBase string
 	GitHubAPIVer  string
+	// GitHub App auth (replaces GITHUB_TOKEN when GitHubAppID is set).
+	GitHubAppID             string
+	GitHubAppPrivateKey     string
+	GitHubAppInstallationID string
+	// GitHubEnterprise switches seats (and metrics) to enterprise scope.
+	// GitHubEnterpriseToken is the PAT for enterprise endpoints, which App
+	// installation tokens cannot read; it is GITHUB_TOKEN even under App auth.
+	GitHubEnterprise      string
+	GitHubEnterpriseToken string
+	// GitHubMetricsDays bounds how far back org metrics are requested (max 28).
+	GitHubMetricsDays int
+	// GitHubConcurrency caps parallel GitHub calls during fan-outs (orgs, teams).
+	GitHubConcurrency int
+	// GitHubRateLimitReserve is the remaining-request floor at which GitHub
+	// calls pause until the rate limit window resets.
+	GitHubRateLimitReserve int
+	// GitHubSeatsTotal adds a cross-org seats snapshot when several orgs run.
+	GitHubSeatsTotal bool
+	// GitHubResolveEmails maps logins to Port user emails (SAML identities,
+	// then verified domain emails) to fill user relations.
+	GitHubResolveEmails bool
+	// GitHubPremiumUsageDays is how many days (ending today) of premium
+	// request usage are (re)ingested each run.
+	GitHubPremiumUsageDays int
+	// GitHubPremiumPerSeatMax allows one premium usage call per seat holder
+	// and day, for up to this many seats, when the report has no per-user
+	// lines; 0 turns that fallback off.
+	GitHubPremiumPerSeatMax int
 
 	// Microsoft Graph
 	MSTenantID     string
@@ -33,14 +64,97 @@ type Config struct {
 	MSClientSecret string
 	GraphAPIBase   string
 	M365Skus       []string
+	// Certificate credentials (PEM; the key may sit in the certificate PEM)
+	// or a federated token file replace the client secret.
+	MSClientCertificate  string
+	MSClientKey          string
+	MSFederatedTokenFile string
+	// GraphCloud names the national cloud (GRAPH_CLOUD); GraphAPIBase and
+	// GraphAuthorityHost default from it, GraphScope from GraphAPIBase.
+	GraphCloud         string
+	GraphAuthorityHost string
+	GraphScope         string
 
 	// Behavior
 	PeriodDays     int
 	SeatsActiveD14 int
+	// M365Periods are the Graph report periods (D7, D30, D90, D180, ALL)
+	// ingested each run, from M365_PERIODS or else PERIOD_DAYS.
+	M365Periods []string
+	// RunTimeout is the deadline for one ingestion run (RUN_TIMEOUT).
+	RunTimeout time.Duration
+
+	// M365 user detail is streamed in batches of M365UserBatchSize rows,
+	// M365UserConcurrency batches in flight; M365UserLimit > 0 stops after
+	// that many users.
+	M365UserBatchSize   int
+	M365UserConcurrency int
+	M365UserLimit       int
+
+	// Seat cost estimates on github_copilot_seats (monthly list prices).
+	SeatPriceBusiness   float64
+	SeatPriceEnterprise float64
+	SeatPriceCurrency   string
+	SeatBillingMode     string
+	SeatIdleDays        int
+
+	// Seat reclamation (`copilot-worker reclaim`)
+	ReclaimInactiveDays int
+	ReclaimAllowUsers   []string
+	ReclaimAllowTeams   []string
+	ReclaimConfirm      bool
+	ReclaimReportFile   string
+
+	// Seat change tracking: previous seat list from a state file or Port.
+	SeatStateSource string
+	SeatStateFile   string
+	// OrgSettingsStateFile keeps each org's last Copilot policies; without
+	// it they are read back from Port (API credentials needed).
+	OrgSettingsStateFile string
+
+	// Audit log: checkpoint file (else Port) and first-run lookback.
+	AuditLogStateFile    string
+	AuditLogLookbackDays int
 
 	// Feature toggles
-	EnableGitHub bool
-	EnableM365   bool
+	EnableGitHub        bool
+	EnableGitHubMetrics bool
+	EnableGitHubTeams   bool
+	EnableM365          bool
+	// GitHubTeamRelationKey picks the _team identifier team_usage points at:
+	// the GitHub team "name" (Port's team sync), its "slug", or "none".
+	GitHubTeamRelationKey string
+	// EnableGitHubSeatDetails emits one github_copilot_seat entity per seat.
+	EnableGitHubSeatDetails bool
+	// EnableGitHubSeatEvents emits github_copilot_seat_event entities for
+	// seats added, removed or changed since the previous run.
+	EnableGitHubSeatEvents bool
+	// EnableGitHubPremiumUsage ingests premium request usage per user/model/day.
+	EnableGitHubPremiumUsage bool
+	// EnableGitHubOrgSettings snapshots org Copilot policies and seat breakdown.
+	EnableGitHubOrgSettings bool
+	// EnableGitHubUsageBreakdown flattens org/enterprise metrics into
+	// github_copilot_usage_breakdown rows (day × editor × model × language).
+	EnableGitHubUsageBreakdown bool
+	// EnableGitHubAuditLog ingests copilot.* org audit log events.
+	EnableGitHubAuditLog bool
+	// EnableM365Trend ingests the daily user count trend as
+	// m365_copilot_usage_trend entities.
+	EnableM365Trend bool
+	// EnableM365Licenses flags M365_COPILOT_SKUS holders without Copilot
+	// activity as m365_copilot_license entities.
+	EnableM365Licenses bool
+	// EnableM365Directory adds Entra ID profile fields to m365_copilot_user
+	// and emits m365_copilot_department rollups.
+	EnableM365Directory bool
+}
+
+// GitHubOrg is one org to ingest and its credentials: a PAT, or with App
+// auth an optional installation ID (empty Token).
+type GitHubOrg struct {
+	Name           string
+	Token          string
+	InstallationID string
 }
 
 // Load parses environment variables into Config with defaults.
@@ -57,6 +171,12 @@ func Load() Config {
 			active14 = n
 		}
 	}
+	metricsDays := 28
+	if s := strings.TrimSpace(os.Getenv("GITHUB_METRICS_DAYS")); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 && n <= 28 {
+			metricsDays = n
+		}
+	}
 	var skus []string
 	if v := strings.TrimSpace(os.Getenv("M365_COPILOT_SKUS")); v != "" {
 		for _, p := range strings.Split(v, ",") {
@@ -67,9 +187,78 @@ func Load() Config {
 	}
 	enableGitHub := boolEnv("INGEST_GITHUB", true)
 	enableM365 := boolEnv("INGEST_M365", true)
+	enterprise := strings.TrimSpace(os.Getenv("GITHUB_ENTERPRISE"))
 	if !enableGitHub && !enableM365 {
 		log.Fatal("set INGEST_GITHUB=true and/or INGEST_M365=true to ingest at least one source")
 	}
+	ghToken := strings.TrimSpace(os.Getenv("GITHUB_TOKEN"))
+	appID := strings.TrimSpace(os.Getenv("GITHUB_APP_ID"))
+	var appKey string
+	if appID != "" {
+		appKey = fileOrEnv("GITHUB_APP_PRIVATE_KEY")
+		if appKey == "" {
+			log.Fatal("GITHUB_APP_ID is set but GITHUB_APP_PRIVATE_KEY(_FILE) is missing")
+		}
+		if enableGitHub && enterprise != "" && ghToken == "" {
+			log.Fatal("GITHUB_ENTERPRISE with GITHUB_APP_ID needs GITHUB_TOKEN: App installation tokens cannot read enterprise endpoints")
+		}
+	}
+	enterpriseToken := ghToken
+	if appID != "" {
+		// App auth wins; the PAT stays only as an explicit per-org override
+		// and for enterprise endpoints.
+		ghToken = ""
+	}
+	seatEvents := enableGitHub && boolEnv("INGEST_GITHUB_SEAT_EVENTS", false)
+	seatSource := strings.ToLower(getOr("SEAT_STATE_SOURCE", "file"))
+	seatFile := strings.TrimSpace(os.Getenv("SEAT_STATE_FILE"))
+	if seatEvents {
+		switch seatSource {
+		case "file":
+			if seatFile == "" {
+				log.Fatal("INGEST_GITHUB_SEAT_EVENTS=true with SEAT_STATE_SOURCE=file needs SEAT_STATE_FILE")
+			}
+		case "port":
+			if !boolEnv("INGEST_GITHUB_SEAT_DETAILS", false) {
+				log.Fatal("SEAT_STATE_SOURCE=port reads github_copilot_seat entities; set INGEST_GITHUB_SEAT_DETAILS=true")
+			}
+		default:
+			log.Fatalf("invalid SEAT_STATE_SOURCE %q (file or port)", seatSource)
+		}
+	}
+	teamKey := strings.ToLower(getOr("GITHUB_TEAM_RELATION_KEY", "name"))
+	if teamKey != "name" && teamKey != "slug" && teamKey != "none" {
+		log.Fatalf("invalid GITHUB_TEAM_RELATION_KEY %q (name, slug or none)", teamKey)
+	}
+	billingMode := strings.ToLower(getOr("SEAT_BILLING_MODE", "prorated"))
+	if billingMode != "prorated" && billingMode != "monthly" {
+		log.Fatalf("invalid SEAT_BILLING_MODE %q (prorated or monthly)", billingMode)
+	}
+	m365Licenses := enableM365 && boolEnv("INGEST_M365_LICENSES", false)
+	if m365Licenses && len(skus) == 0 {
+		log.Fatal("INGEST_M365_LICENSES=true needs M365_COPILOT_SKUS")
+	}
+	msCert := fileOrEnv("MS_CLIENT_CERTIFICATE")
+	msKey := fileOrEnv("MS_CLIENT_KEY")
+	// AKS workload identity injects AZURE_FEDERATED_TOKEN_FILE.
+	msFedFile := getOr("MS_FEDERATED_TOKEN_FILE", strings.TrimSpace(os.Getenv("AZURE_FEDERATED_TOKEN_FILE")))
+	msSecretOptional := !enableM365 || msCert != "" || msFedFile != ""
+	cloudName := strings.ToLower(getOr("GRAPH_CLOUD", "global"))
+	cloud, ok := graphClouds[cloudName]
+	if !ok {
+		log.Fatalf("invalid GRAPH_CLOUD %q (global, usgov, dod or china)", cloudName)
+	}
+	graphBase := getOr("GRAPH_API_BASE", cloud.GraphBase)
+	var orgs []GitHubOrg
+	if enableGitHub {
+		orgs = loadGitHubOrgs(ghToken, appID != "")
+		if len(orgs) == 0 && enterprise == "" {
+			log.Fatal("missing required env: GITHUB_ORGS or GITHUB_ORG")
+		}
+		if ghToken == "" && appID == "" && enterprise != "" {
+			log.Fatal("missing required env: GITHUB_TOKEN")
+		}
+	}
 	return Config{
 		PortRegion:        getOr("PORT_REGION", "eu"),
 		PortClientID:      os.Getenv("PORT_CLIENT_ID"),
@@ -78,24 +267,207 @@ func Load() Config {
 		UseWebhook:        strings.EqualFold(os.Getenv("USE_PORT_WEBHOOK"), "true"),
 		WebhookSecret:     os.Getenv("PORT_WEBHOOK_SECRET"),
 		WebhookSeatsURL:   os.Getenv("PORT_WEBHOOK_SEATS_URL"),
+		WebhookUsageURL:   os.Getenv("PORT_WEBHOOK_USAGE_URL"),
 		WebhookM365SumURL: os.Getenv("PORT_WEBHOOK_M365_SUMMARY_URL"),
 		WebhookM365UsrURL: os.Getenv("PORT_WEBHOOK_M365_USERS_URL"),
-		GitHubOrg:         mustEnv("GITHUB_ORG", !enableGitHub),
-		GitHubToken:       mustEnv("GITHUB_TOKEN", !enableGitHub),
-		GitHubAPIBase:     getOr("GITHUB_API_BASE", "https://api.github.com"),
-		GitHubAPIVer:      getOr("GITHUB_API_VERSION", "2022-11-28"),
-		MSTenantID:        mustEnv("MS_TENANT_ID", !enableM365),
-		MSClientID:        mustEnv("MS_CLIENT_ID", !enableM365),
-		MSClientSecret:    mustEnv("MS_CLIENT_SECRET", !enableM365),
-		GraphAPIBase:      getOr("GRAPH_API_BASE", "https://graph.microsoft.com"),
-		M365Skus:          skus,
-		PeriodDays:        period,
-		SeatsActiveD14:    active14,
-		EnableGitHub:      enableGitHub,
-		EnableM365:        enableM365,
+
+		GitHubOrgs:              orgs,
+		GitHubEnterprise:        enterprise,
+		GitHubEnterpriseToken:   enterpriseToken,
+		GitHubToken:             ghToken,
+		GitHubAPIBase:           getOr("GITHUB_API_BASE", "https://api.github.com"),
+		GitHubAPIVer:            getOr("GITHUB_API_VERSION", "2022-11-28"),
+		GitHubMetricsDays:       metricsDays,
+		GitHubConcurrency:       intEnv("GITHUB_CONCURRENCY", 4),
+		GitHubSeatsTotal:        boolEnv("GITHUB_SEATS_TOTAL", false),
+		GitHubRateLimitReserve:  intEnv("GITHUB_RATE_LIMIT_RESERVE", 100),
+		GitHubResolveEmails:     boolEnv("GITHUB_RESOLVE_EMAILS", false),
+		GitHubPremiumUsageDays:  intEnv("GITHUB_PREMIUM_USAGE_DAYS", 2),
+		GitHubPremiumPerSeatMax: intEnv("GITHUB_PREMIUM_PER_SEAT_MAX", 0),
+
+		GitHubAppID:             appID,
+		GitHubAppPrivateKey:     appKey,
+		GitHubAppInstallationID: strings.TrimSpace(os.Getenv("GITHUB_APP_INSTALLATION_ID")),
+
+		MSTenantID:     mustEnv("MS_TENANT_ID", !enableM365),
+		MSClientID:     mustEnv("MS_CLIENT_ID", !enableM365),
+		MSClientSecret: mustEnv("MS_CLIENT_SECRET", msSecretOptional),
+		GraphAPIBase:   graphBase,
+		M365Skus:       skus,
+
+		MSClientCertificate:  msCert,
+		MSClientKey:          msKey,
+		MSFederatedTokenFile: msFedFile,
+
+		GraphCloud:         cloudName,
+		GraphAuthorityHost: getOr("GRAPH_AUTHORITY_HOST", cloud.AuthorityHost),
+		GraphScope:         getOr("GRAPH_TOKEN_SCOPE", strings.TrimRight(graphBase, "/")+"/.default"),
+
+		PeriodDays:     period,
+		SeatsActiveD14: active14,
+		M365Periods:    m365Periods(period),
+		RunTimeout:     durationEnv("RUN_TIMEOUT", 5*time.Minute),
+
+		M365UserBatchSize:   intEnv("M365_USER_BATCH_SIZE", 20),
+		M365UserConcurrency: intEnv("M365_USER_CONCURRENCY", 4),
+		M365UserLimit:       intEnv("M365_USER_LIMIT", 0),
+
+		SeatPriceBusiness:   priceEnv("SEAT_PRICE_BUSINESS", 19),
+		SeatPriceEnterprise: priceEnv("SEAT_PRICE_ENTERPRISE", 39),
+		SeatPriceCurrency:   getOr("SEAT_PRICE_CURRENCY", "USD"),
+		SeatBillingMode:     billingMode,
+		SeatIdleDays:        intEnv("SEAT_IDLE_DAYS", 30),
+
+		ReclaimInactiveDays: intEnv("RECLAIM_INACTIVE_DAYS", 60),
+		ReclaimAllowUsers:   listEnv("RECLAIM_ALLOW_USERS"),
+		ReclaimAllowTeams:   listEnv("RECLAIM_ALLOW_TEAMS"),
+		ReclaimConfirm:      boolEnv("RECLAIM_CONFIRM", false),
+		ReclaimReportFile:   os.Getenv("RECLAIM_REPORT_FILE"),
+
+		SeatStateSource: seatSource,
+		SeatStateFile:   seatFile,
+
+		OrgSettingsStateFile: strings.TrimSpace(os.Getenv("ORG_SETTINGS_STATE_FILE")),
+
+		AuditLogStateFile:    strings.TrimSpace(os.Getenv("AUDIT_LOG_STATE_FILE")),
+		AuditLogLookbackDays: intEnv("AUDIT_LOG_LOOKBACK_DAYS", 7),
+
+		EnableGitHub:               enableGitHub,
+		EnableGitHubMetrics:        enableGitHub && boolEnv("INGEST_GITHUB_METRICS", true),
+		EnableGitHubTeams:          enableGitHub && boolEnv("INGEST_GITHUB_TEAMS", false),
+		GitHubTeamRelationKey:      teamKey,
+		EnableGitHubSeatDetails:    enableGitHub && boolEnv("INGEST_GITHUB_SEAT_DETAILS", false),
+		EnableGitHubSeatEvents:     seatEvents,
+		EnableGitHubPremiumUsage:   enableGitHub && boolEnv("INGEST_GITHUB_PREMIUM_USAGE", false),
+		EnableGitHubOrgSettings:    enableGitHub && boolEnv("INGEST_GITHUB_ORG_SETTINGS", false),
+		EnableGitHubUsageBreakdown: enableGitHub && boolEnv("INGEST_GITHUB_USAGE_BREAKDOWN", false),
+		EnableGitHubAuditLog:       enableGitHub && boolEnv("INGEST_GITHUB_AUDIT_LOG", false),
+		EnableM365:                 enableM365,
+		EnableM365Trend:            enableM365 && boolEnv("INGEST_M365_TREND", false),
+		EnableM365Licenses:         m365Licenses,
+		EnableM365Directory:        enableM365 && boolEnv("INGEST_M365_DIRECTORY", false),
 	}
 }
 
+// graphCloud is a Microsoft national cloud: where tokens are issued and
+// where Graph lives.
+type graphCloud struct {
+	AuthorityHost string
+	GraphBase     string
+}
+
+// graphClouds maps GRAPH_CLOUD names to their endpoints. GCC (moderate)
+// tenants use the global cloud.
+var graphClouds = map[string]graphCloud{
+	"global": {AuthorityHost: "https://login.microsoftonline.com", GraphBase: "https://graph.microsoft.com"},
+	"usgov":  {AuthorityHost: "https://login.microsoftonline.us", GraphBase: "https://graph.microsoft.us"},
+	"dod":    {AuthorityHost: "https://login.microsoftonline.us", GraphBase: "https://dod-graph.microsoft.us"},
+	"china":  {AuthorityHost: "https://login.chinacloudapi.cn", GraphBase: "https://microsoftgraph.chinacloudapi.cn"},
+}
+
+// m365Periods parses M365_PERIODS (comma-separated report tokens or day
+// counts, e.g. "D7,D30" or "7,30"), defaulting to PERIOD_DAYS.
+func m365Periods(def int) []string {
+	list := listEnv("M365_PERIODS")
+	if len(list) == 0 {
+		return []string{periodToken(def)}
+	}
+	var out []string
+	seen := map[string]bool{}
+	for _, p := range list {
+		tok := strings.ToUpper(p)
+		if n, err := strconv.Atoi(p); err == nil && n > 0 {
+			tok = periodToken(n)
+		}
+		switch tok {
+		case "D7", "D30", "D90", "D180", "ALL":
+		default:
+			log.Fatalf("invalid M365_PERIODS entry %q (D7, D30, D90, D180, ALL or a day count)", p)
+		}
+		if !seen[tok] {
+			seen[tok] = true
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// periodToken maps a day count to the Graph report period that covers it.
+func periodToken(days int) string {
+	switch {
+	case days <= 7:
+		return "D7"
+	case days <= 30:
+		return "D30"
+	case days <= 90:
+		return "D90"
+	case days <= 180:
+		return "D180"
+	default:
+		return "ALL"
+	}
+}
+
+// loadGitHubOrgs reads GITHUB_ORGS (comma-separated), falling back to
+// GITHUB_ORG. An org uses GITHUB_TOKEN_<ORG> when set (org upper-cased,
+// non-alphanumerics as "_"), otherwise the shared token. With App auth an
+// org may pin GITHUB_APP_INSTALLATION_ID_<ORG> instead.
+func loadGitHubOrgs(defToken string, app bool) []GitHubOrg {
+	list := strings.TrimSpace(os.Getenv("GITHUB_ORGS"))
+	if list == "" {
+		list = strings.TrimSpace(os.Getenv("GITHUB_ORG"))
+	}
+	var orgs []GitHubOrg
+	seen := map[string]bool{}
+	for _, p := range strings.Split(list, ",") {
+		name := strings.TrimSpace(p)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		tok := getOr(orgEnvKey("GITHUB_TOKEN_", name), defToken)
+		if tok == "" && !app {
+			log.Fatalf("missing required env: GITHUB_TOKEN or %s", orgEnvKey("GITHUB_TOKEN_", name))
+		}
+		orgs = append(orgs, GitHubOrg{
+			Name:           name,
+			Token:          tok,
+			InstallationID: strings.TrimSpace(os.Getenv(orgEnvKey("GITHUB_APP_INSTALLATION_ID_", name))),
+		})
+	}
+	return orgs
+}
+
+func orgEnvKey(prefix, org string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	for _, r := range strings.ToUpper(org) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// fileOrEnv returns KEY, or the contents of the file named by KEY_FILE
+// (handy for multi-line PEM secrets mounted as files).
+func fileOrEnv(key string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	p := strings.TrimSpace(os.Getenv(key + "_FILE"))
+	if p == "" {
+		return ""
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		log.Fatalf("read %s_FILE: %v", key, err)
+	}
+	return strings.TrimSpace(string(b))
+}
+
 func mustEnv(key string, optional bool) string {
 	v := strings.TrimSpace(os.Getenv(key))
 	if v == "" && !optional {
@@ -111,6 +483,54 @@ func getOr(key, def string) string {
 	return def
 }
 
+// listEnv splits a comma-separated env var, dropping blanks.
+func listEnv(key string) []string {
+	var out []string
+	for _, p := range strings.Split(os.Getenv(key), ",") {
+		if t := strings.TrimSpace(p); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// intEnv returns a positive integer from env, or def when unset/invalid.
+func intEnv(key string, def int) int {
+	if s := strings.TrimSpace(os.Getenv(key)); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
+
+// durationEnv returns a positive duration (e.g. "30m") from env, or def
+// when unset.
+func durationEnv(key string, def time.Duration) time.Duration {
+	s := strings.TrimSpace(os.Getenv(key))
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		log.Fatalf("invalid duration for %s: %q", key, s)
+	}
+	return d
+}
+
+// priceEnv returns a non-negative price from env, or def when unset.
+func priceEnv(key string, def float64) float64 {
+	s := strings.TrimSpace(os.Getenv(key))
+	if s == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		log.Fatalf("invalid price for %s: %q", key, s)
+	}
+	return f
+}
+
 func boolEnv(key string, def bool) bool {
 	v := strings.TrimSpace(os.Getenv(key))
 	if v == "" {
@@ -0,0 +1,217 @@
+package githubapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/tokensource"
+)
+
+// App holds GitHub App credentials used to mint installation tokens.
+type App struct {
+	ID  string
+	Key *rsa.PrivateKey
+	// InstallationID is used for any org without an entry in Installations;
+	// when both are empty the installation is looked up per org.
+	InstallationID string
+	Installations  map[string]string
+}
+
+// ParsePrivateKey decodes the PEM private key GitHub issues for an App
+// (PKCS#1, or PKCS#8 when converted).
+func ParsePrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("github app key: no PEM block")
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("github app key: %w", err)
+	}
+	rk, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app key: not an RSA key")
+	}
+	return rk, nil
+}
+
+// NewAppDoer wraps hc so requests to the GitHub API without an Authorization
+// header carry an installation token for the org (or enterprise) in the
+// path. Tokens are cached per installation and re-minted shortly before
+// they expire (or after a 401), so long runs keep working. Requests to
+// other hosts pass through untouched.
+func NewAppDoer(hc httpx.Doer, base, apiVer string, app App) (httpx.Doer, error) {
+	u, err := url.Parse(strings.TrimRight(base, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("github api base: %w", err)
+	}
+	return &appDoer{
+		next:     hc,
+		base:     strings.TrimRight(base, "/"),
+		host:     u.Host,
+		prefix:   u.Path,
+		apiVer:   apiVer,
+		app:      app,
+		sources:  map[string]*tokensource.Source{},
+		installs: map[string]string{},
+	}, nil
+}
+
+type appDoer struct {
+	next   httpx.Doer
+	base   string
+	host   string
+	prefix string
+	apiVer string
+	app    App
+
+	mu       sync.Mutex
+	sources  map[string]*tokensource.Source
+	installs map[string]string
+}
+
+func (d *appDoer) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != d.host || req.Header.Get("Authorization") != "" {
+		return d.next.Do(req)
+	}
+	org := d.ownerFromPath(req.URL.Path)
+	if org == "" {
+		org = orgFromContext(req.Context())
+	}
+	src, err := d.source(req.Context(), org)
+	if err != nil {
+		return nil, err
+	}
+	return tokensource.Do(d.next, src, req)
+}
+
+// ownerFromPath returns the org login for /orgs/{org}/... (and billing's
+// /organizations/{org}/...) paths, or "" for
+// enterprise and other paths, which use the org the call was made for
+// (GraphQL) or the default installation.
+func (d *appDoer) ownerFromPath(p string) string {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(p, d.prefix), "/"), "/")
+	if len(parts) >= 2 && (parts[0] == "orgs" || parts[0] == "organizations") {
+		return strings.ToLower(parts[1])
+	}
+	return ""
+}
+
+// source returns the token source for the org's installation.
+func (d *appDoer) source(ctx context.Context, org string) (*tokensource.Source, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id, err := d.installationID(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	if src := d.sources[id]; src != nil {
+		return src, nil
+	}
+	src := tokensource.New(func(ctx context.Context) (tokensource.Token, error) {
+		return d.installationToken(ctx, id)
+	})
+	d.sources[id] = src
+	return src, nil
+}
+
+func (d *appDoer) installationToken(ctx context.Context, id string) (tokensource.Token, error) {
+	jwt, err := d.jwt()
+	if err != nil {
+		return tokensource.Token{}, err
+	}
+	var out struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := d.appCall(ctx, "POST", "/app/installations/"+url.PathEscape(id)+"/access_tokens", jwt, &out); err != nil {
+		return tokensource.Token{}, fmt.Errorf("github app installation token: %w", err)
+	}
+	return tokensource.Token{Value: out.Token, Expiry: out.ExpiresAt}, nil
+}
+
+// installationID must be called with d.mu held.
+func (d *appDoer) installationID(ctx context.Context, org string) (string, error) {
+	if id := d.app.Installations[org]; id != "" {
+		return id, nil
+	}
+	if d.app.InstallationID != "" {
+		return d.app.InstallationID, nil
+	}
+	if org == "" {
+		return "", errors.New("github app: GITHUB_APP_INSTALLATION_ID required outside /orgs paths")
+	}
+	if id := d.installs[org]; id != "" {
+		return id, nil
+	}
+	jwt, err := d.jwt()
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		ID int64 `json:"id"`
+	}
+	if err := d.appCall(ctx, "GET", "/orgs/"+url.PathEscape(org)+"/installation", jwt, &out); err != nil {
+		return "", fmt.Errorf("github app installation for %s: %w", org, err)
+	}
+	id := strconv.FormatInt(out.ID, 10)
+	d.installs[org] = id
+	return id, nil
+}
+
+func (d *appDoer) appCall(ctx context.Context, method, path, jwt string, out any) error {
+	req, _ := http.NewRequestWithContext(ctx, method, d.base+path, nil)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", d.apiVer)
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	httpx.SetUserAgent(req)
+	resp, err := httpx.DoWithRetry(ctx, d.next, req, 3)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s", resp.Status, all)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jwt signs the short-lived RS256 App JWT. iat is backdated a minute to
+// absorb clock drift; GitHub caps exp at ten minutes.
+func (d *appDoer) jwt() (string, error) {
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": d.app.ID,
+	})
+	signing := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, d.app.Key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("github app jwt: %w", err)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
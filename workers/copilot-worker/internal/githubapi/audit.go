@@ -0,0 +1,78 @@
+package githubapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// AuditEvent is one org audit log entry. Raw keeps the full entry, since
+// fields beyond these vary by action.
+type AuditEvent struct {
+	DocumentID string          `json:"_document_id"`
+	Action     string          `json:"action"`
+	Actor      string          `json:"actor"`
+	User       string          `json:"user"`
+	Timestamp  int64           `json:"@timestamp"`
+	Raw        json.RawMessage `json:"-"`
+}
+
+// Time returns the event time (@timestamp is in milliseconds).
+func (e AuditEvent) Time() time.Time {
+	return time.UnixMilli(e.Timestamp).UTC()
+}
+
+var nextLinkRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// FetchCopilotAuditLog returns the org's copilot.* audit log events created
+// on or after since's day, oldest first, following the after= cursors in
+// the Link header.
+func FetchCopilotAuditLog(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string, since time.Time) ([]AuditEvent, error) {
+	path := "/orgs/" + url.PathEscape(org) + "/audit-log"
+	q := url.Values{}
+	q.Set("phrase", "action:copilot created:>="+since.UTC().Format("2006-01-02"))
+	q.Set("include", "all")
+	q.Set("order", "asc")
+	q.Set("per_page", "100")
+	var events []AuditEvent
+	for {
+		resp, err := ghGet(ctx, hc, base, apiVer, token, path, q)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 300 {
+			all, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("gh audit log: %s %s", resp.Status, all)
+		}
+		var raw []json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		_ = resp.Body.Close()
+		for _, r := range raw {
+			var e AuditEvent
+			if err := json.Unmarshal(r, &e); err != nil {
+				return nil, fmt.Errorf("decode gh audit event: %w", err)
+			}
+			e.Raw = r
+			events = append(events, e)
+		}
+		m := nextLinkRe.FindStringSubmatch(resp.Header.Get("Link"))
+		if m == nil || len(raw) == 0 {
+			return events, nil
+		}
+		next, err := url.Parse(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("gh audit log next link: %w", err)
+		}
+		q = next.Query()
+	}
+}
@@ -0,0 +1,107 @@
+package githubapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// PremiumUsageItem is one line of the premium request usage report
+// (one SKU/model combination for the filtered period). User names the
+// login a line belongs to when the report is broken down per user.
+type PremiumUsageItem struct {
+	User             string  `json:"user"`
+	Product          string  `json:"product"`
+	SKU              string  `json:"sku"`
+	Model            string  `json:"model"`
+	UnitType         string  `json:"unitType"`
+	PricePerUnit     float64 `json:"pricePerUnit"`
+	GrossQuantity    float64 `json:"grossQuantity"`
+	GrossAmount      float64 `json:"grossAmount"`
+	DiscountQuantity float64 `json:"discountQuantity"`
+	DiscountAmount   float64 `json:"discountAmount"`
+	NetQuantity      float64 `json:"netQuantity"`
+	NetAmount        float64 `json:"netAmount"`
+}
+
+// FetchOrgPremiumUsage returns the Copilot premium request usage of an org
+// for one UTC day, optionally for a single user ("" for the whole org).
+func FetchOrgPremiumUsage(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string, day time.Time, user string) ([]PremiumUsageItem, error) {
+	path := "/organizations/" + url.PathEscape(org) + "/settings/billing/premium_request/usage"
+	return fetchPremiumUsage(ctx, hc, base, apiVer, token, path, day, user)
+}
+
+// FetchEnterprisePremiumUsage is FetchOrgPremiumUsage for an enterprise.
+func FetchEnterprisePremiumUsage(ctx context.Context, hc httpx.Doer, base, apiVer, token, enterprise string, day time.Time, user string) ([]PremiumUsageItem, error) {
+	path := "/enterprises/" + url.PathEscape(enterprise) + "/settings/billing/premium_request/usage"
+	return fetchPremiumUsage(ctx, hc, base, apiVer, token, path, day, user)
+}
+
+func fetchPremiumUsage(ctx context.Context, hc httpx.Doer, base, apiVer, token, path string, day time.Time, user string) ([]PremiumUsageItem, error) {
+	day = day.UTC()
+	q := url.Values{}
+	q.Set("year", strconv.Itoa(day.Year()))
+	q.Set("month", strconv.Itoa(int(day.Month())))
+	q.Set("day", strconv.Itoa(day.Day()))
+	q.Set("product", "Copilot")
+	if user != "" {
+		q.Set("user", user)
+	}
+	resp, err := ghGet(ctx, hc, base, apiVer, token, path, q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("gh premium usage: %s %s", resp.Status, all)
+	}
+	var out struct {
+		UsageItems []PremiumUsageItem `json:"usageItems"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out.UsageItems, nil
+}
+
+// OrgCopilotSettings is GET /orgs/{org}/copilot/billing: the org's Copilot
+// plan, policies and seat breakdown for the current billing cycle.
+type OrgCopilotSettings struct {
+	PlanType              string `json:"plan_type"`
+	SeatManagementSetting string `json:"seat_management_setting"`
+	PublicCodeSuggestions string `json:"public_code_suggestions"`
+	IDEChat               string `json:"ide_chat"`
+	PlatformChat          string `json:"platform_chat"`
+	CLI                   string `json:"cli"`
+	SeatBreakdown         struct {
+		Total               int `json:"total"`
+		AddedThisCycle      int `json:"added_this_cycle"`
+		PendingInvitation   int `json:"pending_invitation"`
+		PendingCancellation int `json:"pending_cancellation"`
+		ActiveThisCycle     int `json:"active_this_cycle"`
+		InactiveThisCycle   int `json:"inactive_this_cycle"`
+	} `json:"seat_breakdown"`
+}
+
+// FetchOrgCopilotSettings returns the org's Copilot billing settings.
+func FetchOrgCopilotSettings(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string) (OrgCopilotSettings, error) {
+	var out OrgCopilotSettings
+	resp, err := ghGet(ctx, hc, base, apiVer, token, "/orgs/"+url.PathEscape(org)+"/copilot/billing", nil)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return out, fmt.Errorf("gh copilot billing: %s %s", resp.Status, all)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	return out, err
+}
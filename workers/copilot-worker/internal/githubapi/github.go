@@ -0,0 +1,86 @@
+package githubapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+func ghGet(ctx context.Context, hc httpx.Doer, base, apiVer, token, path string, q url.Values) (*http.Response, error) {
+	return ghDo(ctx, hc, base, apiVer, token, "GET", path, q, nil)
+}
+
+// ghDo sends a GitHub REST call; body, when non-nil, is sent as JSON.
+func ghDo(ctx context.Context, hc httpx.Doer, base, apiVer, token, method, path string, q url.Values, body []byte) (*http.Response, error) {
+	u := strings.TrimRight(base, "/") + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, _ := http.NewRequestWithContext(ctx, method, u, rd)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", apiVer)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// An empty token leaves auth to hc (see NewAppDoer).
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	httpx.SetUserAgent(req)
+	return httpx.DoWithRetry(ctx, hc, req, 3)
+}
+
+// ghGraphQL runs a GraphQL query on behalf of org (used to pick the App
+// installation, as GraphQL paths carry no org) and decodes data into out.
+// The endpoint is {base}/graphql, or /api/graphql for GHES's /api/v3 base.
+func ghGraphQL(ctx context.Context, hc httpx.Doer, base, apiVer, token, org, query string, vars map[string]any, out any) error {
+	base = strings.TrimRight(base, "/")
+	if strings.HasSuffix(base, "/api/v3") {
+		base = strings.TrimSuffix(base, "/v3")
+	}
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": vars})
+	resp, err := ghDo(withOrg(ctx, org), hc, base, apiVer, token, "POST", "/graphql", nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("gh graphql: %s %s", resp.Status, all)
+	}
+	var env struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return err
+	}
+	if len(env.Errors) > 0 {
+		return fmt.Errorf("gh graphql: %s", env.Errors[0].Message)
+	}
+	return json.Unmarshal(env.Data, out)
+}
+
+type orgCtxKey struct{}
+
+func withOrg(ctx context.Context, org string) context.Context {
+	return context.WithValue(ctx, orgCtxKey{}, strings.ToLower(org))
+}
+
+func orgFromContext(ctx context.Context) string {
+	org, _ := ctx.Value(orgCtxKey{}).(string)
+	return org
+}
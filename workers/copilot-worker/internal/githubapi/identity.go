@@ -0,0 +1,145 @@
+package githubapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+const samlIdentitiesQuery = `query($org: String!, $after: String) {
+  organization(login: $org) {
+    samlIdentityProvider {
+      externalIdentities(first: 100, after: $after) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          samlIdentity { nameId emails { value } }
+          user { login }
+        }
+      }
+    }
+  }
+}`
+
+const verifiedEmailsQuery = `query($org: String!, $after: String) {
+  organization(login: $org) {
+    membersWithRole(first: 100, after: $after) {
+      pageInfo { hasNextPage endCursor }
+      nodes { login organizationVerifiedDomainEmails(login: $org) }
+    }
+  }
+}`
+
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// ResolveEmails maps member logins (lower-cased) to corporate emails for an
+// org: the SAML identity's nameId (or first email) when the org uses SAML
+// SSO, else the member's verified-domain email. Logins with neither are
+// left out. When one source fails the other's matches are still returned
+// alongside the error.
+func ResolveEmails(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string) (map[string]string, error) {
+	out := map[string]string{}
+	samlErr := samlEmails(ctx, hc, base, apiVer, token, org, out)
+	if samlErr != nil {
+		samlErr = fmt.Errorf("saml identities: %w", samlErr)
+	}
+	verifiedErr := verifiedEmails(ctx, hc, base, apiVer, token, org, out)
+	if verifiedErr != nil {
+		verifiedErr = fmt.Errorf("verified emails: %w", verifiedErr)
+	}
+	return out, errors.Join(samlErr, verifiedErr)
+}
+
+func samlEmails(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string, out map[string]string) error {
+	after := ""
+	for {
+		var data struct {
+			Organization struct {
+				SAMLIdentityProvider *struct {
+					ExternalIdentities struct {
+						PageInfo pageInfo `json:"pageInfo"`
+						Nodes    []struct {
+							SAMLIdentity *struct {
+								NameID string `json:"nameId"`
+								Emails []struct {
+									Value string `json:"value"`
+								} `json:"emails"`
+							} `json:"samlIdentity"`
+							User *struct {
+								Login string `json:"login"`
+							} `json:"user"`
+						} `json:"nodes"`
+					} `json:"externalIdentities"`
+				} `json:"samlIdentityProvider"`
+			} `json:"organization"`
+		}
+		if err := ghGraphQL(ctx, hc, base, apiVer, token, org, samlIdentitiesQuery, pageVars(org, after), &data); err != nil {
+			return err
+		}
+		idp := data.Organization.SAMLIdentityProvider
+		if idp == nil {
+			// No org-level SAML (or SAML lives at the enterprise).
+			return nil
+		}
+		for _, n := range idp.ExternalIdentities.Nodes {
+			if n.User == nil || n.SAMLIdentity == nil {
+				continue
+			}
+			email := n.SAMLIdentity.NameID
+			if !strings.Contains(email, "@") && len(n.SAMLIdentity.Emails) > 0 {
+				email = n.SAMLIdentity.Emails[0].Value
+			}
+			if strings.Contains(email, "@") {
+				out[strings.ToLower(n.User.Login)] = strings.ToLower(email)
+			}
+		}
+		if !idp.ExternalIdentities.PageInfo.HasNextPage {
+			return nil
+		}
+		after = idp.ExternalIdentities.PageInfo.EndCursor
+	}
+}
+
+func verifiedEmails(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string, out map[string]string) error {
+	after := ""
+	for {
+		var data struct {
+			Organization struct {
+				MembersWithRole struct {
+					PageInfo pageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						Login  string   `json:"login"`
+						Emails []string `json:"organizationVerifiedDomainEmails"`
+					} `json:"nodes"`
+				} `json:"membersWithRole"`
+			} `json:"organization"`
+		}
+		if err := ghGraphQL(ctx, hc, base, apiVer, token, org, verifiedEmailsQuery, pageVars(org, after), &data); err != nil {
+			return err
+		}
+		m := data.Organization.MembersWithRole
+		for _, n := range m.Nodes {
+			login := strings.ToLower(n.Login)
+			if _, ok := out[login]; !ok && len(n.Emails) > 0 {
+				out[login] = strings.ToLower(n.Emails[0])
+			}
+		}
+		if !m.PageInfo.HasNextPage {
+			return nil
+		}
+		after = m.PageInfo.EndCursor
+	}
+}
+
+func pageVars(org, after string) map[string]any {
+	vars := map[string]any{"org": org}
+	if after != "" {
+		vars["after"] = after
+	}
+	return vars
+}
@@ -0,0 +1,247 @@
+package githubapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// DayMetrics is one day of the Copilot metrics response. Raw keeps the
+// original JSON so it can be stored as the usage entity's breakdown.
+type DayMetrics struct {
+	Date              string          `json:"date"`
+	TotalActiveUsers  int             `json:"total_active_users"`
+	TotalEngagedUsers int             `json:"total_engaged_users"`
+	CodeCompletions   CodeCompletions `json:"copilot_ide_code_completions"`
+	IDEChat           IDEChat         `json:"copilot_ide_chat"`
+	DotcomChat        DotcomChat      `json:"copilot_dotcom_chat"`
+	Raw               json.RawMessage `json:"-"`
+}
+
+// CodeCompletions is the copilot_ide_code_completions section.
+type CodeCompletions struct {
+	TotalEngagedUsers int                `json:"total_engaged_users"`
+	Languages         []EngagedName      `json:"languages"`
+	Editors           []CompletionEditor `json:"editors"`
+}
+
+// EngagedName is a name with its engaged-user count (languages, editors).
+type EngagedName struct {
+	Name              string `json:"name"`
+	TotalEngagedUsers int    `json:"total_engaged_users"`
+}
+
+// CompletionEditor holds per-editor code completion metrics.
+type CompletionEditor struct {
+	Name              string            `json:"name"`
+	TotalEngagedUsers int               `json:"total_engaged_users"`
+	Models            []CompletionModel `json:"models"`
+}
+
+// CompletionModel holds per-model code completion metrics.
+type CompletionModel struct {
+	Name              string               `json:"name"`
+	IsCustomModel     bool                 `json:"is_custom_model"`
+	TotalEngagedUsers int                  `json:"total_engaged_users"`
+	Languages         []CompletionLanguage `json:"languages"`
+}
+
+// CompletionLanguage holds the per-language counters under a model.
+type CompletionLanguage struct {
+	Name                    string `json:"name"`
+	TotalEngagedUsers       int    `json:"total_engaged_users"`
+	TotalCodeSuggestions    int    `json:"total_code_suggestions"`
+	TotalCodeAcceptances    int    `json:"total_code_acceptances"`
+	TotalCodeLinesSuggested int    `json:"total_code_lines_suggested"`
+	TotalCodeLinesAccepted  int    `json:"total_code_lines_accepted"`
+}
+
+// IDEChat is the copilot_ide_chat section.
+type IDEChat struct {
+	TotalEngagedUsers int          `json:"total_engaged_users"`
+	Editors           []ChatEditor `json:"editors"`
+}
+
+// ChatEditor holds per-editor IDE chat metrics.
+type ChatEditor struct {
+	Name              string      `json:"name"`
+	TotalEngagedUsers int         `json:"total_engaged_users"`
+	Models            []ChatModel `json:"models"`
+}
+
+// DotcomChat is the copilot_dotcom_chat section (Copilot Chat on github.com).
+type DotcomChat struct {
+	TotalEngagedUsers int         `json:"total_engaged_users"`
+	Models            []ChatModel `json:"models"`
+}
+
+// ChatModel holds per-model chat counters; github.com chat has no
+// insertion or copy events.
+type ChatModel struct {
+	Name                     string `json:"name"`
+	IsCustomModel            bool   `json:"is_custom_model"`
+	TotalEngagedUsers        int    `json:"total_engaged_users"`
+	TotalChats               int    `json:"total_chats"`
+	TotalChatInsertionEvents int    `json:"total_chat_insertion_events"`
+	TotalChatCopyEvents      int    `json:"total_chat_copy_events"`
+}
+
+// UsageTotals are the per-day aggregates the mapping override computes in jq.
+type UsageTotals struct {
+	Suggestions     int
+	Acceptances     int
+	LinesSuggested  int
+	LinesAccepted   int
+	ActiveUsers     int
+	ChatAcceptances int
+	ChatTurns       int
+	ActiveChatUsers int
+	EditorTop       string
+	LanguageTop     string
+}
+
+// Totals sums the editor → model → language tree the same way
+// configs/mappings/github_copilot_mapping_override.yaml does.
+func (d DayMetrics) Totals() UsageTotals {
+	t := UsageTotals{ActiveUsers: d.TotalActiveUsers}
+	for _, e := range d.CodeCompletions.Editors {
+		for _, m := range e.Models {
+			for _, l := range m.Languages {
+				t.Suggestions += l.TotalCodeSuggestions
+				t.Acceptances += l.TotalCodeAcceptances
+				t.LinesSuggested += l.TotalCodeLinesSuggested
+				t.LinesAccepted += l.TotalCodeLinesAccepted
+			}
+		}
+	}
+	for _, e := range d.IDEChat.Editors {
+		t.ActiveChatUsers += e.TotalEngagedUsers
+		for _, m := range e.Models {
+			t.ChatTurns += m.TotalChats
+			t.ChatAcceptances += m.TotalChatCopyEvents + m.TotalChatInsertionEvents
+		}
+	}
+	editors := make([]EngagedName, 0, len(d.CodeCompletions.Editors))
+	for _, e := range d.CodeCompletions.Editors {
+		editors = append(editors, EngagedName{Name: e.Name, TotalEngagedUsers: e.TotalEngagedUsers})
+	}
+	t.EditorTop = topEngaged(editors)
+	t.LanguageTop = topEngaged(d.CodeCompletions.Languages)
+	return t
+}
+
+// Breakdown features (BreakdownRow.Feature).
+const (
+	FeatureCodeCompletions = "code_completions"
+	FeatureIDEChat         = "ide_chat"
+	FeatureDotcomChat      = "dotcom_chat"
+)
+
+// DotcomEditor is the editor name given to github.com chat rows.
+const DotcomEditor = "github.com"
+
+// BreakdownRow is one leaf of the metrics trees: editor × model × language
+// for code completions (Language set), editor × model for IDE chat and
+// model for github.com chat (Chat set).
+type BreakdownRow struct {
+	Feature       string
+	Editor        string
+	Model         string
+	IsCustomModel bool
+	Language      CompletionLanguage
+	Chat          ChatModel
+}
+
+// Breakdown flattens the code completions, IDE chat and github.com chat
+// trees into one row per leaf.
+func (d DayMetrics) Breakdown() []BreakdownRow {
+	var rows []BreakdownRow
+	for _, e := range d.CodeCompletions.Editors {
+		for _, m := range e.Models {
+			for _, l := range m.Languages {
+				rows = append(rows, BreakdownRow{Feature: FeatureCodeCompletions, Editor: e.Name, Model: m.Name, IsCustomModel: m.IsCustomModel, Language: l})
+			}
+		}
+	}
+	for _, e := range d.IDEChat.Editors {
+		for _, m := range e.Models {
+			rows = append(rows, BreakdownRow{Feature: FeatureIDEChat, Editor: e.Name, Model: m.Name, IsCustomModel: m.IsCustomModel, Chat: m})
+		}
+	}
+	for _, m := range d.DotcomChat.Models {
+		rows = append(rows, BreakdownRow{Feature: FeatureDotcomChat, Editor: DotcomEditor, Model: m.Name, IsCustomModel: m.IsCustomModel, Chat: m})
+	}
+	return rows
+}
+
+// FetchOrgMetrics returns daily Copilot metrics for an org since the given day.
+func FetchOrgMetrics(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string, since time.Time) ([]DayMetrics, error) {
+	return fetchMetrics(ctx, hc, base, apiVer, token, "/orgs/"+url.PathEscape(org)+"/copilot/metrics", since)
+}
+
+// FetchEnterpriseMetrics returns daily Copilot metrics for a whole enterprise.
+func FetchEnterpriseMetrics(ctx context.Context, hc httpx.Doer, base, apiVer, token, enterprise string, since time.Time) ([]DayMetrics, error) {
+	return fetchMetrics(ctx, hc, base, apiVer, token, "/enterprises/"+url.PathEscape(enterprise)+"/copilot/metrics", since)
+}
+
+func fetchMetrics(ctx context.Context, hc httpx.Doer, base, apiVer, token, path string, since time.Time) ([]DayMetrics, error) {
+	var days []DayMetrics
+	page := 1
+	for {
+		q := url.Values{}
+		q.Set("per_page", "100")
+		q.Set("page", strconv.Itoa(page))
+		if !since.IsZero() {
+			q.Set("since", since.UTC().Format(time.RFC3339))
+		}
+		resp, err := ghGet(ctx, hc, base, apiVer, token, path, q)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == 404 {
+			_ = resp.Body.Close()
+			return days, nil
+		}
+		if resp.StatusCode >= 300 {
+			all, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("gh metrics: %s %s", resp.Status, all)
+		}
+		var raw []json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		_ = resp.Body.Close()
+		for _, r := range raw {
+			var d DayMetrics
+			if err := json.Unmarshal(r, &d); err != nil {
+				return nil, fmt.Errorf("decode gh metrics day: %w", err)
+			}
+			d.Raw = r
+			days = append(days, d)
+		}
+		if len(raw) < 100 {
+			break
+		}
+		page++
+	}
+	return days, nil
+}
+
+func topEngaged(items []EngagedName) string {
+	top := ""
+	best := -1
+	for _, it := range items {
+		if it.TotalEngagedUsers > best {
+			top, best = it.Name, it.TotalEngagedUsers
+		}
+	}
+	return top
+}
@@ -0,0 +1,221 @@
+package githubapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// RateLimiter is a Doer for GitHub API calls that tracks the
+// X-RateLimit-* headers per credential and resource and pauses before the
+// remaining budget drops to the reserve. It does not retry: secondary rate
+// limit responses (403/429) hold off further calls with that credential
+// for the delay GitHub asks for and are handed back as a 429 with
+// Retry-After, so httpx.DoWithRetry stays the only retry layer. Other hosts
+// pass through untouched.
+type RateLimiter struct {
+	next    httpx.Doer
+	host    string
+	reserve int
+
+	mu      sync.Mutex
+	budgets map[string]*rateBudget
+	paused  time.Duration
+	pauses  int
+	limited int
+}
+
+type rateBudget struct {
+	limit     int
+	remaining int
+	used      int
+	reset     time.Time
+	holdUntil time.Time
+	calls     int
+}
+
+// NewRateLimiter wraps hc for the GitHub API at base. reserve is the
+// remaining-request floor at which calls wait for the window to reset.
+func NewRateLimiter(hc httpx.Doer, base string, reserve int) (*RateLimiter, error) {
+	u, err := url.Parse(strings.TrimRight(base, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("github api base: %w", err)
+	}
+	return &RateLimiter{next: hc, host: u.Host, reserve: reserve, budgets: map[string]*rateBudget{}}, nil
+}
+
+func (rl *RateLimiter) Do(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != rl.host {
+		return rl.next.Do(req)
+	}
+	key := credKey(req.Header.Get("Authorization")) + "/" + resourceFor(req)
+	if err := rl.waitForBudget(req, key); err != nil {
+		return nil, err
+	}
+	resp, err := rl.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	rl.record(key, resp)
+	if wait, limited := secondaryLimitWait(resp); limited {
+		rl.holdOff(key, wait)
+		asRetryable(resp, wait)
+	}
+	return resp, nil
+}
+
+// Report summarizes rate limit consumption for the end-of-run log.
+func (rl *RateLimiter) Report() string {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	keys := make([]string, 0, len(rl.budgets))
+	for k := range rl.budgets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		bu := rl.budgets[k]
+		fmt.Fprintf(&b, "%s: %d calls, %d/%d used, %d remaining (reset %s); ",
+			k, bu.calls, bu.used, bu.limit, bu.remaining, bu.reset.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "paused %d time(s) for %s, %d secondary-limit response(s)", rl.pauses, rl.paused.Round(time.Second), rl.limited)
+	return b.String()
+}
+
+func (rl *RateLimiter) waitForBudget(req *http.Request, key string) error {
+	rl.mu.Lock()
+	var wait time.Duration
+	if bu := rl.budgets[key]; bu != nil {
+		if bu.limit > 0 && bu.remaining <= rl.reserve {
+			wait = time.Until(bu.reset) + time.Second
+		}
+		wait = max(wait, time.Until(bu.holdUntil))
+	}
+	rl.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	return rl.sleep(req, wait)
+}
+
+// holdOff makes calls with key wait d after a secondary rate limit, which
+// GitHub applies per credential rather than per request.
+func (rl *RateLimiter) holdOff(key string, d time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.limited++
+	bu := rl.budgets[key]
+	if until := time.Now().Add(d); until.After(bu.holdUntil) {
+		bu.holdUntil = until
+	}
+}
+
+// asRetryable turns a secondary rate limit response into a 429 carrying
+// Retry-After, which httpx.DoWithRetry honours; a rate-limited 403 would
+// otherwise read as a permission error.
+func asRetryable(resp *http.Response, d time.Duration) {
+	resp.StatusCode = http.StatusTooManyRequests
+	resp.Status = "429 Too Many Requests"
+	resp.Header.Set("Retry-After", strconv.Itoa(int(d.Round(time.Second)/time.Second)))
+}
+
+func (rl *RateLimiter) sleep(req *http.Request, d time.Duration) error {
+	rl.mu.Lock()
+	rl.pauses++
+	rl.paused += d
+	rl.mu.Unlock()
+	select {
+	case <-time.After(d):
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+func (rl *RateLimiter) record(key string, resp *http.Response) {
+	h := resp.Header
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	bu := rl.budgets[key]
+	if bu == nil {
+		bu = &rateBudget{}
+		rl.budgets[key] = bu
+	}
+	bu.calls++
+	if n, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		bu.limit = n
+	}
+	if n, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		bu.remaining = n
+	}
+	if n, err := strconv.Atoi(h.Get("X-RateLimit-Used")); err == nil {
+		bu.used = n
+	}
+	if n, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		bu.reset = time.Unix(n, 0)
+	}
+}
+
+// secondaryLimitWait reports whether resp is a (secondary) rate limit
+// rejection and how long to wait: Retry-After, else until X-RateLimit-Reset
+// when the budget is exhausted, else one minute as GitHub recommends. The
+// body is restored when the response is handed back to the caller.
+func secondaryLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != 403 && resp.StatusCode != 429 {
+		return 0, false
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	exhausted := resp.Header.Get("X-RateLimit-Remaining") == "0"
+	if resp.StatusCode == 403 && !exhausted && resp.Header.Get("Retry-After") == "" &&
+		!strings.Contains(strings.ToLower(string(body)), "rate limit") {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if exhausted {
+		if n, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			if d := time.Until(time.Unix(n, 0)); d > 0 {
+				return d + time.Second, true
+			}
+		}
+	}
+	return time.Minute, true
+}
+
+// resourceFor guesses the rate limit bucket a request draws from; GitHub
+// only names it (X-RateLimit-Resource) in the response.
+func resourceFor(req *http.Request) string {
+	if strings.HasSuffix(req.URL.Path, "/graphql") {
+		return "graphql"
+	}
+	return "core"
+}
+
+// credKey fingerprints a credential so budgets can be told apart without
+// keeping or logging the token itself.
+func credKey(auth string) string {
+	if auth == "" {
+		return "anonymous"
+	}
+	// App JWTs change on every call but share the App's own budget.
+	if strings.HasPrefix(auth, "Bearer eyJ") {
+		return "app-jwt"
+	}
+	sum := sha256.Sum256([]byte(auth))
+	return "token-" + hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,229 @@
+package githubapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecondaryLimitWait(t *testing.T) {
+	reset := time.Now().Add(30 * time.Second).Unix()
+	tests := []struct {
+		name    string
+		status  int
+		header  map[string]string
+		body    string
+		limited bool
+		min     time.Duration
+		max     time.Duration
+	}{
+		{
+			name:    "403 with Retry-After",
+			status:  403,
+			header:  map[string]string{"Retry-After": "7"},
+			body:    `{"message":"You have exceeded a secondary rate limit"}`,
+			limited: true, min: 7 * time.Second, max: 7 * time.Second,
+		},
+		{
+			name:    "403 with exhausted budget waits for reset",
+			status:  403,
+			header:  map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(reset, 10)},
+			body:    `{"message":"API rate limit exceeded"}`,
+			limited: true, min: 25 * time.Second, max: 32 * time.Second,
+		},
+		{
+			name:    "403 mentioning rate limit defaults to a minute",
+			status:  403,
+			body:    `{"message":"secondary rate limit"}`,
+			limited: true, min: time.Minute, max: time.Minute,
+		},
+		{
+			name:   "plain 403 permission error",
+			status: 403,
+			header: map[string]string{"X-RateLimit-Remaining": "4999"},
+			body:   `{"message":"Resource not accessible by integration"}`,
+		},
+		{
+			name:    "429 without headers",
+			status:  429,
+			limited: true, min: time.Minute, max: time.Minute,
+		},
+		{
+			name:   "200",
+			status: 200,
+			body:   `[]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tt.body))}
+			for k, v := range tt.header {
+				resp.Header.Set(k, v)
+			}
+			wait, limited := secondaryLimitWait(resp)
+			if limited != tt.limited {
+				t.Fatalf("limited = %v, want %v", limited, tt.limited)
+			}
+			if limited && (wait < tt.min || wait > tt.max) {
+				t.Errorf("wait = %s, want between %s and %s", wait, tt.min, tt.max)
+			}
+			if tt.status == 403 || tt.status == 429 {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.body {
+					t.Errorf("body not restored: %q", body)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterRewritesOnlySecondaryLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     map[string]string
+		body       string
+		wantStatus int
+		wantRetry  string
+	}{
+		{
+			name:       "secondary limit becomes a retryable 429",
+			header:     map[string]string{"Retry-After": "3"},
+			body:       `{"message":"secondary rate limit"}`,
+			wantStatus: 429,
+			wantRetry:  "3",
+		},
+		{
+			name:       "permission error passes through",
+			body:       `{"message":"Must have admin rights to Repository."}`,
+			wantStatus: 403,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(403)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+			rl, err := NewRateLimiter(srv.Client(), srv.URL, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, _ := http.NewRequest("GET", srv.URL+"/orgs/acme/copilot/billing/seats", nil)
+			req.Header.Set("Authorization", "Bearer tok")
+			resp, err := rl.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := resp.Header.Get("Retry-After"); got != tt.wantRetry {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetry)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+			bu := rl.budgets[credKey("Bearer tok")+"/core"]
+			if held := bu.holdUntil.After(time.Now()); held != (tt.wantStatus == 429) {
+				t.Errorf("holdUntil set = %v, want %v", held, tt.wantStatus == 429)
+			}
+		})
+	}
+}
+
+func TestRateLimiterHoldOffDelaysNextCall(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(429)
+			return
+		}
+		_, _ = io.WriteString(w, "[]")
+	}))
+	defer srv.Close()
+	rl, _ := NewRateLimiter(srv.Client(), srv.URL, 10)
+
+	req, _ := http.NewRequest("GET", srv.URL+"/orgs/acme/teams", nil)
+	resp, err := rl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	start := time.Now()
+	req, _ = http.NewRequest("GET", srv.URL+"/orgs/acme/teams", nil)
+	resp, err = rl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("second call went out after %s; want it held off for about a second", elapsed)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2 (no retries inside the limiter)", calls)
+	}
+}
+
+func TestRateLimiterReservePauseHonoursContext(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "3")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		_, _ = io.WriteString(w, "[]")
+	}))
+	defer srv.Close()
+	rl, _ := NewRateLimiter(srv.Client(), srv.URL, 10)
+
+	req, _ := http.NewRequest("GET", srv.URL+"/orgs/acme/teams", nil)
+	resp, err := rl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ = http.NewRequestWithContext(ctx, "GET", srv.URL+"/orgs/acme/teams", nil)
+	start := time.Now()
+	_, err = rl.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("pause ignored cancellation for %s", elapsed)
+	}
+}
+
+func TestRateLimiterIgnoresOtherHosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(403)
+		_, _ = io.WriteString(w, "rate limit")
+	}))
+	defer srv.Close()
+	rl, _ := NewRateLimiter(srv.Client(), "https://api.github.com", 10)
+	req, _ := http.NewRequest("GET", srv.URL+"/x", nil)
+	resp, err := rl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 403 {
+		t.Errorf("status = %d, want 403 untouched", resp.StatusCode)
+	}
+}
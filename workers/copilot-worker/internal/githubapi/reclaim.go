@@ -0,0 +1,70 @@
+package githubapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// ListTeamMembers returns the logins of every member of an org team.
+func ListTeamMembers(ctx context.Context, hc httpx.Doer, base, apiVer, token, org, teamSlug string) ([]string, error) {
+	var logins []string
+	page := 1
+	for {
+		q := url.Values{}
+		q.Set("per_page", "100")
+		q.Set("page", strconv.Itoa(page))
+		path := "/orgs/" + url.PathEscape(org) + "/teams/" + url.PathEscape(teamSlug) + "/members"
+		resp, err := ghGet(ctx, hc, base, apiVer, token, path, q)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 300 {
+			all, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("gh team members: %s %s", resp.Status, all)
+		}
+		var out []Account
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		_ = resp.Body.Close()
+		for _, a := range out {
+			logins = append(logins, a.Login)
+		}
+		if len(out) < 100 {
+			break
+		}
+		page++
+	}
+	return logins, nil
+}
+
+// RemoveSeats cancels Copilot seats for the given users
+// (DELETE /orgs/{org}/copilot/billing/selected_users). Seats stay usable
+// until the end of the billing cycle; the count GitHub cancelled is returned.
+func RemoveSeats(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string, logins []string) (int, error) {
+	body, _ := json.Marshal(map[string]any{"selected_usernames": logins})
+	resp, err := ghDo(ctx, hc, base, apiVer, token, "DELETE", "/orgs/"+url.PathEscape(org)+"/copilot/billing/selected_users", nil, body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("gh remove seats: %s %s", resp.Status, all)
+	}
+	var out struct {
+		SeatsCancelled int `json:"seats_cancelled"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return 0, err
+	}
+	return out.SeatsCancelled, nil
+}
@@ -5,33 +5,70 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
 )
 
-// Seat is the subset of the Copilot seats response we care about.
+// Seat is one Copilot seat assignment. AssignedAt maps to created_at.
 type Seat struct {
-	AssignedAt     *time.Time `json:"assigned_at"`
-	LastActivityAt *time.Time `json:"last_activity_at"`
+	AssignedAt              *time.Time `json:"created_at"`
+	UpdatedAt               *time.Time `json:"updated_at"`
+	LastActivityAt          *time.Time `json:"last_activity_at"`
+	LastActivityEditor      string     `json:"last_activity_editor"`
+	PendingCancellationDate string     `json:"pending_cancellation_date"`
+	PlanType                string     `json:"plan_type"`
+	Assignee                *Account   `json:"assignee"`
+	AssigningTeam           *Team      `json:"assigning_team"`
+	// Organization is only populated by the enterprise seats endpoint.
+	Organization *Account `json:"organization"`
+}
+
+// Account is a GitHub user or org reference embedded in responses.
+type Account struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+	// Email is only set when the user exposes a public email.
+	Email string `json:"email"`
+}
+
+// Login returns the assignee login, or "" for unassigned payloads.
+func (s Seat) Login() string {
+	if s.Assignee == nil {
+		return ""
+	}
+	return s.Assignee.Login
+}
+
+// Org returns the login of the org that granted the seat, or "".
+func (s Seat) Org() string {
+	if s.Organization == nil {
+		return ""
+	}
+	return s.Organization.Login
 }
 
 // FetchSeats pages through Copilot seat assignments for an org.
 func FetchSeats(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string) ([]Seat, error) {
+	return fetchSeats(ctx, hc, base, apiVer, token, "/orgs/"+url.PathEscape(org)+"/copilot/billing/seats")
+}
+
+// FetchEnterpriseSeats pages through Copilot seat assignments for an
+// enterprise; each seat carries the org it was assigned through.
+func FetchEnterpriseSeats(ctx context.Context, hc httpx.Doer, base, apiVer, token, enterprise string) ([]Seat, error) {
+	return fetchSeats(ctx, hc, base, apiVer, token, "/enterprises/"+url.PathEscape(enterprise)+"/copilot/billing/seats")
+}
+
+func fetchSeats(ctx context.Context, hc httpx.Doer, base, apiVer, token, path string) ([]Seat, error) {
 	var seats []Seat
 	page := 1
 	for {
-		ep := fmt.Sprintf("%s/orgs/%s/copilot/billing/seats?per_page=100&page=%d",
-			strings.TrimRight(base, "/"), url.PathEscape(org), page)
-		req, _ := http.NewRequestWithContext(ctx, "GET", ep, nil)
-		req.Header.Set("Accept", "application/vnd.github+json")
-		req.Header.Set("X-GitHub-Api-Version", apiVer)
-		req.Header.Set("Authorization", "Bearer "+token)
-		httpx.SetUserAgent(req)
-		resp, err := httpx.DoWithRetry(ctx, hc, req, 3)
+		q := url.Values{}
+		q.Set("per_page", "100")
+		q.Set("page", strconv.Itoa(page))
+		resp, err := ghGet(ctx, hc, base, apiVer, token, path, q)
 		if err != nil {
 			return nil, err
 		}
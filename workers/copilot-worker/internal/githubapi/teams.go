@@ -0,0 +1,58 @@
+package githubapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// Team is the subset of an org team we need to fan out metrics calls.
+type Team struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// ListTeams pages through every team visible in the org.
+func ListTeams(ctx context.Context, hc httpx.Doer, base, apiVer, token, org string) ([]Team, error) {
+	var teams []Team
+	page := 1
+	for {
+		q := url.Values{}
+		q.Set("per_page", "100")
+		q.Set("page", strconv.Itoa(page))
+		resp, err := ghGet(ctx, hc, base, apiVer, token, "/orgs/"+url.PathEscape(org)+"/teams", q)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 300 {
+			all, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("gh teams: %s %s", resp.Status, all)
+		}
+		var out []Team
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			_ = resp.Body.Close()
+			return nil, err
+		}
+		_ = resp.Body.Close()
+		teams = append(teams, out...)
+		if len(out) < 100 {
+			break
+		}
+		page++
+	}
+	return teams, nil
+}
+
+// FetchTeamMetrics returns daily Copilot metrics for one team. Teams with
+// fewer than five licensed members yield no days.
+func FetchTeamMetrics(ctx context.Context, hc httpx.Doer, base, apiVer, token, org, teamSlug string, since time.Time) ([]DayMetrics, error) {
+	path := "/orgs/" + url.PathEscape(org) + "/team/" + url.PathEscape(teamSlug) + "/copilot/metrics"
+	return fetchMetrics(ctx, hc, base, apiVer, token, path, since)
+}
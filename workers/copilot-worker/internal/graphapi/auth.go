@@ -0,0 +1,199 @@
+package graphapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/tokensource"
+)
+
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// Global cloud defaults for Credentials without AuthorityHost or Scope.
+const (
+	defaultAuthorityHost = "https://login.microsoftonline.com"
+	defaultScope         = "https://graph.microsoft.com/.default"
+)
+
+// Credentials identify the Entra ID app registration used for Graph. The
+// first one set wins: a federated token file, a certificate, a secret.
+type Credentials struct {
+	// AuthorityHost and Scope select the national cloud; empty means the
+	// global cloud.
+	AuthorityHost string
+	Scope         string
+
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// Certificate and Key sign a client assertion JWT (x5t = SHA-1
+	// thumbprint of the certificate).
+	Certificate *x509.Certificate
+	Key         *rsa.PrivateKey
+	// FederatedTokenFile holds an externally issued JWT (AKS workload
+	// identity, GitHub OIDC) used as the assertion. It is re-read for every
+	// token because the issuer rotates it.
+	FederatedTokenFile string
+}
+
+// NewTokenSource returns a cached Graph token source for c; tokens are
+// re-requested shortly before expires_in runs out.
+func NewTokenSource(hc httpx.Doer, c Credentials) *tokensource.Source {
+	return tokensource.New(func(ctx context.Context) (tokensource.Token, error) {
+		return Token(ctx, hc, c)
+	})
+}
+
+// Token performs client-credentials OAuth2 against Entra ID for Graph.
+func Token(ctx context.Context, hc httpx.Doer, c Credentials) (tokensource.Token, error) {
+	authority, scope := c.AuthorityHost, c.Scope
+	if authority == "" {
+		authority = defaultAuthorityHost
+	}
+	if scope == "" {
+		scope = defaultScope
+	}
+	ep := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimRight(authority, "/"), url.PathEscape(c.TenantID))
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+	data.Set("client_id", c.ClientID)
+	data.Set("scope", scope)
+	switch {
+	case c.FederatedTokenFile != "":
+		b, err := os.ReadFile(c.FederatedTokenFile)
+		if err != nil {
+			return tokensource.Token{}, fmt.Errorf("graph federated token: %w", err)
+		}
+		data.Set("client_assertion_type", clientAssertionType)
+		data.Set("client_assertion", strings.TrimSpace(string(b)))
+	case c.Certificate != nil:
+		assertion, err := clientAssertion(c, ep)
+		if err != nil {
+			return tokensource.Token{}, err
+		}
+		data.Set("client_assertion_type", clientAssertionType)
+		data.Set("client_assertion", assertion)
+	default:
+		data.Set("client_secret", c.ClientSecret)
+	}
+	req, _ := http.NewRequestWithContext(ctx, "POST", ep, strings.NewReader(data.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpx.SetUserAgent(req)
+	resp, err := httpx.DoWithRetry(ctx, hc, req, 3)
+	if err != nil {
+		return tokensource.Token{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return tokensource.Token{}, fmt.Errorf("graph token: %s %s", resp.Status, all)
+	}
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return tokensource.Token{}, err
+	}
+	return tokensource.Token{Value: out.AccessToken, Expiry: tokensource.ExpiresIn(out.ExpiresIn)}, nil
+}
+
+// clientAssertion signs the RS256 JWT Entra ID expects for certificate
+// credentials; aud is the token endpoint.
+func clientAssertion(c Credentials, aud string) (string, error) {
+	thumb := sha1.Sum(c.Certificate.Raw)
+	header, _ := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+		"x5t": base64.RawURLEncoding.EncodeToString(thumb[:]),
+	})
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims, _ := json.Marshal(map[string]any{
+		"aud": aud,
+		"iss": c.ClientID,
+		"sub": c.ClientID,
+		"jti": hex.EncodeToString(jti),
+		"nbf": now.Add(-time.Minute).Unix(),
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(10 * time.Minute).Unix(),
+	})
+	signing := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.Key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("graph client assertion: %w", err)
+	}
+	return signing + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// LoadCertificate reads the first certificate from certPEM and the RSA
+// private key from keyPEM, or from certPEM when keyPEM is empty (a
+// combined PEM as exported for app registrations).
+func LoadCertificate(certPEM, keyPEM []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	if len(keyPEM) == 0 {
+		keyPEM = certPEM
+	}
+	var cert *x509.Certificate
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			c, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("graph certificate: %w", err)
+			}
+			cert = c
+			break
+		}
+	}
+	if cert == nil {
+		return nil, nil, errors.New("graph certificate: no CERTIFICATE block")
+	}
+	for rest := keyPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, nil, errors.New("graph certificate: no private key block")
+		}
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+		if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			return cert, k, nil
+		}
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("graph certificate key: %w", err)
+		}
+		rk, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, errors.New("graph certificate key: not an RSA key")
+		}
+		return cert, rk, nil
+	}
+}
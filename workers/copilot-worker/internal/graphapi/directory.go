@@ -0,0 +1,144 @@
+package graphapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// maxBatchRequests is the most requests Graph accepts in one $batch.
+const maxBatchRequests = 20
+
+// Profile is the organisational context of a directory user.
+type Profile struct {
+	Department     string
+	JobTitle       string
+	OfficeLocation string
+	CompanyName    string
+	ManagerName    string
+	ManagerUPN     string
+}
+
+// DirectoryProfiles looks up department, job title, office, company and
+// manager for each UPN through JSON $batch (20 users per call). Keys are
+// lower-cased UPNs; users Graph cannot find are left out. Throttled
+// sub-requests are retried after their Retry-After, up to three times.
+func DirectoryProfiles(ctx context.Context, hc httpx.Doer, base string, upns []string) (map[string]Profile, error) {
+	out := make(map[string]Profile, len(upns))
+	for start := 0; start < len(upns); start += maxBatchRequests {
+		pending := upns[start:min(start+maxBatchRequests, len(upns))]
+		for attempt := 0; len(pending) > 0; attempt++ {
+			throttled, wait, err := profileBatch(ctx, hc, base, pending, out)
+			if err != nil {
+				return out, err
+			}
+			if len(throttled) == 0 {
+				break
+			}
+			if attempt >= 3 {
+				return out, fmt.Errorf("graph batch: %d user(s) still throttled", len(throttled))
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return out, ctx.Err()
+			}
+			pending = throttled
+		}
+	}
+	return out, nil
+}
+
+// profileBatch sends one $batch for upns, stores found profiles in out and
+// returns the throttled UPNs with the longest Retry-After.
+func profileBatch(ctx context.Context, hc httpx.Doer, base string, upns []string, out map[string]Profile) ([]string, time.Duration, error) {
+	type subRequest struct {
+		ID     string `json:"id"`
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	}
+	reqs := make([]subRequest, 0, len(upns))
+	for i, upn := range upns {
+		reqs = append(reqs, subRequest{
+			ID:     strconv.Itoa(i),
+			Method: "GET",
+			URL: "/users/" + url.PathEscape(upn) +
+				"?$select=department,jobTitle,officeLocation,companyName&$expand=manager($select=displayName,userPrincipalName)",
+		})
+	}
+	b, _ := json.Marshal(map[string]any{"requests": reqs})
+	req, _ := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(base, "/")+"/v1.0/$batch", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	httpx.SetUserAgent(req)
+	resp, err := httpx.DoWithRetry(ctx, hc, req, 3)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return nil, 0, fmt.Errorf("graph batch: %s %s", resp.Status, all)
+	}
+	var res struct {
+		Responses []struct {
+			ID      string            `json:"id"`
+			Status  int               `json:"status"`
+			Headers map[string]string `json:"headers"`
+			Body    struct {
+				Department     string `json:"department"`
+				JobTitle       string `json:"jobTitle"`
+				OfficeLocation string `json:"officeLocation"`
+				CompanyName    string `json:"companyName"`
+				Manager        *struct {
+					DisplayName       string `json:"displayName"`
+					UserPrincipalName string `json:"userPrincipalName"`
+				} `json:"manager"`
+			} `json:"body"`
+		} `json:"responses"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, 0, fmt.Errorf("decode graph batch: %w", err)
+	}
+	var throttled []string
+	var wait time.Duration
+	for _, r := range res.Responses {
+		i, err := strconv.Atoi(r.ID)
+		if err != nil || i < 0 || i >= len(upns) {
+			continue
+		}
+		switch {
+		case r.Status == http.StatusOK:
+			p := Profile{
+				Department:     r.Body.Department,
+				JobTitle:       r.Body.JobTitle,
+				OfficeLocation: r.Body.OfficeLocation,
+				CompanyName:    r.Body.CompanyName,
+			}
+			if m := r.Body.Manager; m != nil {
+				p.ManagerName, p.ManagerUPN = m.DisplayName, m.UserPrincipalName
+			}
+			out[strings.ToLower(upns[i])] = p
+		case r.Status == http.StatusTooManyRequests || r.Status >= 500:
+			throttled = append(throttled, upns[i])
+			d := 5 * time.Second
+			for k, v := range r.Headers {
+				if strings.EqualFold(k, "Retry-After") {
+					if secs, err := strconv.Atoi(v); err == nil {
+						d = time.Duration(secs) * time.Second
+					}
+				}
+			}
+			wait = max(wait, d)
+		}
+	}
+	return throttled, wait, nil
+}
@@ -1,6 +1,10 @@
+// Package graphapi calls Microsoft Graph. Functions send requests without
+// credentials; hc must add them (NewTokenSource with tokensource.NewDoer),
+// so tokens are refreshed in one place.
 package graphapi
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/csv"
@@ -17,41 +21,15 @@ import (
 	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
 )
 
-// Token performs client-credentials OAuth2 against Entra ID for Graph.
-func Token(ctx context.Context, hc httpx.Doer, tenant, clientID, clientSecret string) (string, error) {
-	data := url.Values{}
-	data.Set("grant_type", "client_credentials")
-	data.Set("client_id", clientID)
-	data.Set("client_secret", clientSecret)
-	data.Set("scope", "https://graph.microsoft.com/.default")
-	ep := fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", tenant)
-	req, _ := http.NewRequestWithContext(ctx, "POST", ep, strings.NewReader(data.Encode()))
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	httpx.SetUserAgent(req)
-	resp, err := httpx.DoWithRetry(ctx, hc, req, 3)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		all, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("graph token: %s %s", resp.Status, all)
-	}
-	var out struct {
-		AccessToken string `json:"access_token"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", err
-	}
-	return out.AccessToken, nil
-}
-
-// CopilotSummary fetches tenant-level summary for a chosen period (beta).
-func CopilotSummary(ctx context.Context, hc httpx.Doer, base, token, period string) (map[string]any, error) {
+// CopilotSummary fetches tenant-level summary for a chosen period (beta) as
+// enabledUserCount, activeUserCount and, when given, reportRefreshDate (the
+// date Graph last refreshed the report), all read from the report's first
+// row whether it comes back as JSON or CSV.
+func CopilotSummary(ctx context.Context, hc httpx.Doer, base, period string) (map[string]any, error) {
 	q := url.Values{}
 	q.Set("$format", "application/json")
 	path := fmt.Sprintf("/beta/reports/getMicrosoft365CopilotUserCountSummary(period='%s')", period)
-	resp, err := graphGet(ctx, hc, base, token, path, q)
+	resp, err := graphGet(ctx, hc, base, path, q)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +43,7 @@ func CopilotSummary(ctx context.Context, hc httpx.Doer, base, token, period stri
 	}
 	var out map[string]any
 	if err := json.Unmarshal(body, &out); err == nil && len(out) > 0 {
-		return out, nil
+		return summaryFromRow(summaryJSONRow(out)), nil
 	}
 	summary, err := parseSummaryCSV(body)
 	if err != nil {
@@ -74,33 +52,167 @@ func CopilotSummary(ctx context.Context, hc httpx.Doer, base, token, period stri
 	return summary, nil
 }
 
-// CopilotUserDetail returns per-user last-activity fields.
-func CopilotUserDetail(ctx context.Context, hc httpx.Doer, base, token, period string) ([]map[string]any, error) {
+// CopilotUserDetail streams per-user last-activity rows to fn, following
+// @odata.nextLink across pages. Each page may be CSV, a bare JSON array or
+// the JSON {"value": [...]} envelope; rows are decoded one at a time rather
+// than buffered. An error from fn stops the walk and is returned.
+func CopilotUserDetail(ctx context.Context, hc httpx.Doer, base, period string, fn func(map[string]any) error) error {
 	q := url.Values{}
 	q.Set("$format", "application/json")
 	path := fmt.Sprintf("/beta/reports/getMicrosoft365CopilotUsageUserDetail(period='%s')", period)
-	resp, err := graphGet(ctx, hc, base, token, path, q)
+	next := strings.TrimRight(base, "/") + path + "?" + q.Encode()
+	for next != "" {
+		resp, err := graphGetURL(ctx, hc, next)
+		if err != nil {
+			return err
+		}
+		link, err := readUserDetailPage(resp, fn)
+		_ = resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if link == next {
+			return fmt.Errorf("graph user detail: nextLink repeats %s", link)
+		}
+		next = link
+	}
+	return nil
+}
+
+// readUserDetailPage streams one page to fn and returns its nextLink.
+func readUserDetailPage(resp *http.Response, fn func(map[string]any) error) (string, error) {
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("graph user detail: %s %s", resp.Status, all)
+	}
+	br := bufio.NewReader(resp.Body)
+	first, err := skipBOMAndSpace(br)
+	if err == io.EOF {
+		return "", nil
+	}
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	switch first {
+	case '{':
+		return streamJSONEnvelope(json.NewDecoder(br), fn)
+	case '[':
+		return "", streamJSONArray(json.NewDecoder(br), fn)
+	default:
+		return "", streamUserDetailCSV(br, fn)
+	}
+}
+
+// skipBOMAndSpace consumes a leading BOM and whitespace and returns the
+// next rune without consuming it.
+func skipBOMAndSpace(br *bufio.Reader) (rune, error) {
+	for {
+		r, _, err := br.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r == '\ufeff' || unicode.IsSpace(r) {
+			continue
+		}
+		return r, br.UnreadRune()
+	}
+}
+
+// streamJSONEnvelope walks {"value": [...], "@odata.nextLink": "..."} in
+// whatever key order Graph sends it.
+func streamJSONEnvelope(dec *json.Decoder, fn func(map[string]any) error) (string, error) {
+	if _, err := dec.Token(); err != nil {
+		return "", fmt.Errorf("decode user detail: %w", err)
+	}
+	var next string
+	for dec.More() {
+		kt, err := dec.Token()
+		if err != nil {
+			return "", fmt.Errorf("decode user detail: %w", err)
+		}
+		switch kt {
+		case "value":
+			if err := streamJSONArray(dec, fn); err != nil {
+				return "", err
+			}
+		case "@odata.nextLink":
+			if err := dec.Decode(&next); err != nil {
+				return "", fmt.Errorf("decode user detail nextLink: %w", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return "", fmt.Errorf("decode user detail: %w", err)
+			}
+		}
+	}
+	return next, nil
+}
+
+func streamJSONArray(dec *json.Decoder, fn func(map[string]any) error) error {
+	t, err := dec.Token()
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("decode user detail: %w", err)
 	}
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("graph user detail: %s %s", resp.Status, body)
+	if t != json.Delim('[') {
+		return fmt.Errorf("decode user detail: want array, got %v", t)
 	}
-	var arr []map[string]any
-	if err := json.Unmarshal(body, &arr); err == nil && arr != nil {
-		return arr, nil
+	for dec.More() {
+		var row map[string]any
+		if err := dec.Decode(&row); err != nil {
+			return fmt.Errorf("decode user detail row: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+func streamUserDetailCSV(r io.Reader, fn func(map[string]any) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	headers, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("decode user detail csv: %w", err)
+	}
+	targets := userFieldTargets()
+	for i, h := range headers {
+		headers[i] = normalizeKey(h)
+	}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("decode user detail csv: %w", err)
+		}
+		user := map[string]any{}
+		for i, v := range rec {
+			if v = strings.TrimSpace(v); v == "" || i >= len(headers) {
+				continue
+			}
+			if target, ok := targets[headers[i]]; ok {
+				user[target] = v
+			}
+		}
+		if len(user) == 0 {
+			continue
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
 	}
-	return parseUserDetailCSV(body)
 }
 
 // SubscribedSkus lists SKUs; we count configured Copilot skuPartNumbers only.
-func SubscribedSkus(ctx context.Context, hc httpx.Doer, base, token string) ([]map[string]any, error) {
-	resp, err := graphGet(ctx, hc, base, token, "/v1.0/subscribedSkus", nil)
+func SubscribedSkus(ctx context.Context, hc httpx.Doer, base string) ([]map[string]any, error) {
+	resp, err := graphGet(ctx, hc, base, "/v1.0/subscribedSkus", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -118,13 +230,17 @@ func SubscribedSkus(ctx context.Context, hc httpx.Doer, base, token string) ([]m
 	return out.Value, nil
 }
 
-func graphGet(ctx context.Context, hc httpx.Doer, base, token, path string, q url.Values) (*http.Response, error) {
+func graphGet(ctx context.Context, hc httpx.Doer, base, path string, q url.Values) (*http.Response, error) {
 	u := strings.TrimRight(base, "/") + path
 	if len(q) > 0 {
 		u += "?" + q.Encode()
 	}
+	return graphGetURL(ctx, hc, u)
+}
+
+// graphGetURL fetches an absolute URL such as an @odata.nextLink.
+func graphGetURL(ctx context.Context, hc httpx.Doer, u string) (*http.Response, error) {
 	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
-	req.Header.Set("Authorization", "Bearer "+token)
 	httpx.SetUserAgent(req)
 	return httpx.DoWithRetry(ctx, hc, req, 3)
 }
@@ -137,42 +253,60 @@ func parseSummaryCSV(body []byte) (map[string]any, error) {
 	if len(rows) == 0 {
 		return nil, errors.New("graph summary: empty report")
 	}
-	row := normalizeRow(rows[0])
+	return summaryFromRow(normalizeRow(rows[0])), nil
+}
+
+// summaryFromRow reads the summary fields from one report row keyed like the
+// normalized CSV headers, so the JSON and CSV forms are read the same way.
+func summaryFromRow(row map[string]string) map[string]any {
 	summary := map[string]any{}
 	summary["enabledUserCount"] = atoiAny(row, []string{
 		"microsoft365_copilot_enabled_user_count",
+		"microsoft_365_copilot_enabled_user_count",
 		"enabled_user_count",
+		"any_app_enabled_users",
 	})
 	summary["activeUserCount"] = atoiAny(row, []string{
 		"microsoft365_copilot_active_user_count",
+		"microsoft_365_copilot_active_user_count",
 		"active_user_count",
+		"any_app_active_users",
 	})
 	if rd := firstNonEmpty(row, []string{"report_refresh_date", "report_date"}); rd != "" {
-		summary["reportDate"] = rd
+		summary["reportRefreshDate"] = rd
 	}
-	return summary, nil
+	return summary
 }
 
-func parseUserDetailCSV(body []byte) ([]map[string]any, error) {
-	rows, err := parseCSVRows(body)
-	if err != nil {
-		return nil, fmt.Errorf("decode user detail csv: %w", err)
+// summaryJSONRow flattens the first row of a JSON summary (the first entry
+// of a {"value": [...]} envelope, else the object itself) into a row keyed
+// like the normalized CSV headers. Nested count objects (e.g. enabledUsers,
+// activeUsers), or the first object of a nested array, are merged in
+// without overriding the row's own fields.
+func summaryJSONRow(out map[string]any) map[string]string {
+	first := out
+	if rows, ok := out["value"].([]any); ok {
+		first = nil
+		if len(rows) > 0 {
+			first, _ = rows[0].(map[string]any)
+		}
 	}
-	var result []map[string]any
-	for _, raw := range rows {
-		n := normalizeRow(raw)
-		user := map[string]any{}
-		for key, target := range userFieldTargets() {
-			if val := n[key]; val != "" {
-				user[target] = val
-			}
+	row := scalarRow(first)
+	for _, v := range first {
+		nested, ok := v.(map[string]any)
+		if list, isList := v.([]any); isList && len(list) > 0 {
+			nested, ok = list[0].(map[string]any)
 		}
-		if len(user) == 0 {
+		if !ok {
 			continue
 		}
-		result = append(result, user)
+		for k, nv := range scalarRow(nested) {
+			if _, set := row[k]; !set {
+				row[k] = nv
+			}
+		}
 	}
-	return result, nil
+	return row
 }
 
 func parseCSVRows(body []byte) ([]map[string]string, error) {
@@ -262,6 +396,8 @@ func firstNonEmpty(row map[string]string, keys []string) string {
 
 func userFieldTargets() map[string]string {
 	return map[string]string{
+		"report_refresh_date": "reportRefreshDate",
+		"reportrefreshdate":   "reportRefreshDate",
 		"user_principal_name": "userPrincipalName",
 		"userprincipalname":   "userPrincipalName",
 		"display_name":        "displayName",
@@ -0,0 +1,130 @@
+package graphapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// TrendDay is one day of the Copilot user count trend. App counts are the
+// active users per Copilot surface.
+type TrendDay struct {
+	ReportDate        string
+	ReportRefreshDate string
+	EnabledUsers      int
+	ActiveUsers       int
+	TeamsActive       int
+	WordActive        int
+	ExcelActive       int
+	PowerPointActive  int
+	OutlookActive     int
+	OneNoteActive     int
+	LoopActive        int
+	CopilotChatActive int
+}
+
+// CopilotUserCountTrend returns the per-day enabled/active user counts for
+// the period (beta). JSON nests the days under value[].adoptionByDate; CSV
+// has one row per day. Both are normalized the same way.
+func CopilotUserCountTrend(ctx context.Context, hc httpx.Doer, base, period string) ([]TrendDay, error) {
+	q := url.Values{}
+	q.Set("$format", "application/json")
+	path := fmt.Sprintf("/beta/reports/getMicrosoft365CopilotUserCountTrend(period='%s')", period)
+	resp, err := graphGet(ctx, hc, base, path, q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("graph trend: %s %s", resp.Status, body)
+	}
+	var rows []map[string]string
+	var env struct {
+		Value []map[string]any `json:"value"`
+	}
+	if err := json.Unmarshal(body, &env); err == nil && env.Value != nil {
+		rows = trendJSONRows(env.Value)
+	} else {
+		csvRows, err := parseCSVRows(body)
+		if err != nil {
+			return nil, fmt.Errorf("decode trend csv: %w", err)
+		}
+		for _, r := range csvRows {
+			rows = append(rows, normalizeRow(r))
+		}
+	}
+	var days []TrendDay
+	for _, r := range rows {
+		d := TrendDay{
+			ReportDate:        r["report_date"],
+			ReportRefreshDate: r["report_refresh_date"],
+			EnabledUsers:      atoiAny(r, []string{"enabled_users"}),
+			ActiveUsers:       atoiAny(r, []string{"active_users"}),
+			TeamsActive:       atoiAny(r, []string{"microsoft_teams_active_users", "teams_active_users"}),
+			WordActive:        atoiAny(r, []string{"word_active_users"}),
+			ExcelActive:       atoiAny(r, []string{"excel_active_users"}),
+			PowerPointActive:  atoiAny(r, []string{"power_point_active_users", "powerpoint_active_users"}),
+			OutlookActive:     atoiAny(r, []string{"outlook_active_users"}),
+			OneNoteActive:     atoiAny(r, []string{"one_note_active_users", "onenote_active_users"}),
+			LoopActive:        atoiAny(r, []string{"loop_active_users"}),
+			CopilotChatActive: atoiAny(r, []string{"copilot_chat_active_users"}),
+		}
+		if d.ReportDate == "" {
+			continue
+		}
+		days = append(days, d)
+	}
+	return days, nil
+}
+
+// trendJSONRows flattens value[].adoptionByDate[] into rows keyed like the
+// normalized CSV headers, copying the parent's scalar fields (report
+// refresh date, period) onto each day. Entries without adoptionByDate are
+// taken as flat day rows.
+func trendJSONRows(value []map[string]any) []map[string]string {
+	var rows []map[string]string
+	for _, v := range value {
+		parent := scalarRow(v)
+		days, ok := v["adoptionByDate"].([]any)
+		if !ok {
+			rows = append(rows, parent)
+			continue
+		}
+		for _, d := range days {
+			m, ok := d.(map[string]any)
+			if !ok {
+				continue
+			}
+			row := scalarRow(m)
+			for k, pv := range parent {
+				if _, set := row[k]; !set {
+					row[k] = pv
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func scalarRow(m map[string]any) map[string]string {
+	row := map[string]string{}
+	for k, v := range m {
+		switch t := v.(type) {
+		case string:
+			row[normalizeKey(k)] = t
+		case float64:
+			row[normalizeKey(k)] = strconv.FormatFloat(t, 'f', -1, 64)
+		}
+	}
+	return row
+}
@@ -0,0 +1,98 @@
+package graphapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+)
+
+// LicensedUser is a directory user holding at least one of the requested
+// SKUs. LicenseUpdatedAt is the latest licenseAssignmentStates
+// lastUpdatedDateTime among those SKUs (RFC3339, may be empty); Graph does
+// not expose when a license was first assigned.
+type LicensedUser struct {
+	ID                string
+	UserPrincipalName string
+	DisplayName       string
+	SkuIDs            []string
+	LicenseUpdatedAt  string
+}
+
+type directoryUser struct {
+	ID                      string `json:"id"`
+	UserPrincipalName       string `json:"userPrincipalName"`
+	DisplayName             string `json:"displayName"`
+	LicenseAssignmentStates []struct {
+		SkuID               string `json:"skuId"`
+		State               string `json:"state"`
+		LastUpdatedDateTime string `json:"lastUpdatedDateTime"`
+	} `json:"licenseAssignmentStates"`
+}
+
+// LicensedUsers pages /users filtered on assignedLicenses for each SKU id
+// (one query per SKU, merged by user id).
+func LicensedUsers(ctx context.Context, hc httpx.Doer, base string, skuIDs []string) ([]LicensedUser, error) {
+	byID := map[string]*LicensedUser{}
+	var order []string
+	for _, sku := range skuIDs {
+		q := url.Values{}
+		q.Set("$filter", fmt.Sprintf("assignedLicenses/any(x:x/skuId eq %s)", sku))
+		q.Set("$select", "id,userPrincipalName,displayName,licenseAssignmentStates")
+		q.Set("$top", "999")
+		next := strings.TrimRight(base, "/") + "/v1.0/users?" + q.Encode()
+		for next != "" {
+			var page struct {
+				Value    []directoryUser `json:"value"`
+				NextLink string          `json:"@odata.nextLink"`
+			}
+			if err := graphGetJSON(ctx, hc, next, &page); err != nil {
+				return nil, fmt.Errorf("graph licensed users: %w", err)
+			}
+			for _, u := range page.Value {
+				lu := byID[u.ID]
+				if lu == nil {
+					lu = &LicensedUser{ID: u.ID, UserPrincipalName: u.UserPrincipalName, DisplayName: u.DisplayName}
+					byID[u.ID] = lu
+					order = append(order, u.ID)
+				}
+				lu.SkuIDs = append(lu.SkuIDs, sku)
+				for _, st := range u.LicenseAssignmentStates {
+					if !strings.EqualFold(st.SkuID, sku) || st.LastUpdatedDateTime == "" {
+						continue
+					}
+					if st.LastUpdatedDateTime > lu.LicenseUpdatedAt {
+						lu.LicenseUpdatedAt = st.LastUpdatedDateTime
+					}
+				}
+			}
+			if page.NextLink == next {
+				return nil, fmt.Errorf("graph licensed users: nextLink repeats %s", next)
+			}
+			next = page.NextLink
+		}
+	}
+	out := make([]LicensedUser, 0, len(order))
+	for _, id := range order {
+		out = append(out, *byID[id])
+	}
+	return out, nil
+}
+
+// graphGetJSON fetches an absolute Graph URL and decodes the JSON body.
+func graphGetJSON(ctx context.Context, hc httpx.Doer, u string, out any) error {
+	resp, err := graphGetURL(ctx, hc, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		all, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s", resp.Status, all)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,143 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// auditTimeFormat keeps the audit log's millisecond precision so the
+// checkpoint does not drop events from the same second.
+const auditTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// GitHubAuditLog ingests every org's copilot.* audit log events (seat
+// assignments, cancellations, policy changes) as github_copilot_audit_event
+// entities, linked to the actor and the affected user, resuming from each
+// org's checkpoint (the last event time seen).
+func GitHubAuditLog(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client) {
+	checkpoints, err := loadAuditCheckpoints(ctx, cfg, pcli)
+	if err != nil {
+		log.Printf("warn: gh audit log: checkpoints: %v; reading the last %d day(s)", err, cfg.AuditLogLookbackDays)
+	}
+	var mu sync.Mutex
+	next := map[string]string{}
+	for org, cp := range checkpoints {
+		next[org] = cp
+	}
+	forEachOrg(cfg, func(org config.GitHubOrg) {
+		since := time.Now().UTC().AddDate(0, 0, -cfg.AuditLogLookbackDays)
+		if t, err := time.Parse(auditTimeFormat, checkpoints[org.Name]); err == nil {
+			since = t
+		}
+		events, err := githubapi.FetchCopilotAuditLog(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name, since)
+		if err != nil {
+			log.Printf("warn: gh audit log %s: %v", org.Name, err)
+			return
+		}
+		emails := orgEmails(ctx, cfg, hc, org.Name, org.Token)
+		last, count := since, 0
+		for _, e := range events {
+			// The API filters by day; events at the checkpoint itself are
+			// re-sent, which is harmless as identifiers are stable.
+			if e.Time().Before(since) {
+				continue
+			}
+			if err := auditEvent(ctx, cfg, hc, pcli, org.Name, e, emails); err != nil {
+				log.Printf("warn: gh audit event %s/%s: %v", org.Name, e.DocumentID, err)
+				// Stop here so the next run retries from this event.
+				break
+			}
+			count++
+			if e.Time().After(last) {
+				last = e.Time()
+			}
+		}
+		log.Printf("gh audit log: %d copilot event(s) for %s since %s", count, org.Name, since.Format(time.RFC3339))
+		mu.Lock()
+		next[org.Name] = last.Format(auditTimeFormat)
+		mu.Unlock()
+	})
+	if cfg.AuditLogStateFile == "" {
+		return
+	}
+	if err := writeStateFile(cfg.AuditLogStateFile, next); err != nil {
+		log.Printf("warn: gh audit log: save checkpoints: %v", err)
+	}
+}
+
+func auditEvent(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, org string, e githubapi.AuditEvent, emails map[string]string) error {
+	props := map[string]any{
+		"action":      e.Action,
+		"actor":       e.Actor,
+		"git_hub_org": org,
+		"created_at":  e.Time().Format(auditTimeFormat),
+		"details":     e.Raw,
+	}
+	rels := map[string]any{}
+	if email := emails[strings.ToLower(e.Actor)]; email != "" {
+		rels["actor_user"] = email
+	}
+	if e.User != "" {
+		props["affected_user"] = e.User
+		if email := emails[strings.ToLower(e.User)]; email != "" {
+			rels["affected_port_user"] = email
+		}
+		if cfg.EnableGitHubSeatDetails {
+			rels["seat"] = org + "/" + e.User
+		}
+	}
+	return upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-audit-event", "github_copilot_audit_event",
+		org+"/"+e.DocumentID, props, rels)
+}
+
+// loadAuditCheckpoints reads each org's last event time from
+// AUDIT_LOG_STATE_FILE, else from the newest github_copilot_audit_event
+// entities when the Port API is available, else starts from the lookback.
+func loadAuditCheckpoints(ctx context.Context, cfg config.Config, pcli *portapi.Client) (map[string]string, error) {
+	out := map[string]string{}
+	if cfg.AuditLogStateFile != "" {
+		b, err := os.ReadFile(cfg.AuditLogStateFile)
+		if errors.Is(err, os.ErrNotExist) {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		if err := json.Unmarshal(b, &out); err != nil {
+			return map[string]string{}, fmt.Errorf("decode %s: %w", cfg.AuditLogStateFile, err)
+		}
+		return out, nil
+	}
+	if pcli == nil {
+		return out, nil
+	}
+	ents, err := pcli.ListEntities(ctx, "github_copilot_audit_event")
+	if err != nil {
+		return out, err
+	}
+	for _, e := range ents {
+		org, at := str(e.Properties["git_hub_org"]), str(e.Properties["created_at"])
+		if org == "" {
+			continue
+		}
+		// Port may return a different (but still sortable) date-time form.
+		if t, err := time.Parse(time.RFC3339Nano, at); err == nil {
+			at = t.UTC().Format(auditTimeFormat)
+		}
+		if at > out[org] {
+			out[org] = at
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// gitHubSeatEntities upserts one github_copilot_seat per assignee, keyed by
+// org/login so each entity tracks the seat's latest state across runs.
+// emails (login -> email, may be nil) relates seats to Port users. It
+// returns the logins whose upsert failed.
+func gitHubSeatEntities(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, seats []githubapi.Seat, org string, emails map[string]string, snapshotID, recordDate string) map[string]bool {
+	var unmatched []string
+	failed := map[string]bool{}
+	count := 0
+	for _, s := range seats {
+		login := s.Login()
+		if login == "" {
+			continue
+		}
+		rels := map[string]any{"seats_snapshot": snapshotID}
+		if email := seatEmail(s, emails); email != "" {
+			rels["port_user"] = email
+		} else {
+			unmatched = append(unmatched, login)
+		}
+		if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-seat", "github_copilot_seat",
+			org+"/"+login, seatProps(s, org, recordDate), rels); err != nil {
+			log.Printf("warn: gh seat %s/%s: %v", org, login, err)
+			failed[login] = true
+			continue
+		}
+		count++
+	}
+	log.Printf("gh seats: %d seat entities for %s", count, org)
+	if cfg.GitHubResolveEmails {
+		logUnmatched(org, unmatched)
+	}
+	return failed
+}
+
+func seatProps(s githubapi.Seat, org, recordDate string) map[string]any {
+	props := map[string]any{
+		"record_date":          recordDate,
+		"login":                s.Assignee.Login,
+		"assignee_type":        s.Assignee.Type,
+		"git_hub_org":          org,
+		"plan_type":            s.PlanType,
+		"last_activity_editor": s.LastActivityEditor,
+	}
+	if s.AssigningTeam != nil {
+		props["assigning_team"] = s.AssigningTeam.Slug
+	}
+	if s.AssignedAt != nil {
+		props["assigned_at"] = s.AssignedAt.UTC().Format(time.RFC3339)
+	}
+	if s.UpdatedAt != nil {
+		props["updated_at"] = s.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	if s.LastActivityAt != nil {
+		props["last_activity_at"] = s.LastActivityAt.UTC().Format(time.RFC3339)
+	}
+	// Always set so a reactivated seat clears the date on upsert.
+	props["pending_cancellation_date"] = nil
+	if s.PendingCancellationDate != "" {
+		props["pending_cancellation_date"] = s.PendingCancellationDate + "T00:00:00Z"
+	}
+	return props
+}
@@ -0,0 +1,162 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// GitHubUsage ingests one github_copilot_usage entity per day of org metrics
+// for every configured org. Identifiers match Port's built-in integration
+// (org@date) so reruns and the mapping override upsert the same entities.
+func GitHubUsage(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client) {
+	since := time.Now().UTC().AddDate(0, 0, -cfg.GitHubMetricsDays)
+	forEachOrg(cfg, func(org config.GitHubOrg) {
+		days, err := githubapi.FetchOrgMetrics(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name, since)
+		if err != nil {
+			log.Printf("warn: gh metrics %s: %v", org.Name, err)
+			return
+		}
+		breakdowns := 0
+		for _, d := range days {
+			id := org.Name + "@" + d.Date
+			if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookUsageURL, "gh-copilot-usage", "github_copilot_usage",
+				id, usageProps(d, org.Name, ""), nil); err != nil {
+				log.Printf("warn: gh usage %s: %v", id, err)
+				continue
+			}
+			if cfg.EnableGitHubUsageBreakdown {
+				breakdowns += usageBreakdown(ctx, cfg, hc, pcli, d, id, org.Name, map[string]any{"git_hub_org": org.Name})
+			}
+		}
+		log.Printf("gh usage: %d day(s), %d breakdown row(s) for %s", len(days), breakdowns, org.Name)
+	})
+}
+
+// GitHubTeamUsage ingests per-team daily usage for every configured org.
+// Orgs run one after another; within an org the team metrics endpoint is
+// called with at most cfg.GitHubConcurrency requests in flight.
+func GitHubTeamUsage(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client) {
+	for _, org := range cfg.GitHubOrgs {
+		gitHubOrgTeamUsage(ctx, cfg, hc, pcli, org)
+	}
+}
+
+func gitHubOrgTeamUsage(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, org config.GitHubOrg) {
+	teams, err := githubapi.ListTeams(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name)
+	if err != nil {
+		log.Printf("warn: gh teams %s: %v", org.Name, err)
+		return
+	}
+	since := time.Now().UTC().AddDate(0, 0, -cfg.GitHubMetricsDays)
+	sem := make(chan struct{}, cfg.GitHubConcurrency)
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		emitted int
+	)
+	for _, t := range teams {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t githubapi.Team) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			days, err := githubapi.FetchTeamMetrics(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name, t.Slug, since)
+			if err != nil {
+				log.Printf("warn: gh team metrics %s/%s: %v", org.Name, t.Slug, err)
+				return
+			}
+			var rels map[string]any
+			if id := portTeamID(cfg, t); id != "" {
+				rels = map[string]any{"team_usage": id}
+			}
+			for _, d := range days {
+				id := org.Name + "/" + t.Slug + "@" + d.Date
+				if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookUsageURL, "gh-copilot-usage", "github_copilot_usage",
+					id, usageProps(d, org.Name, t.Slug), rels); err != nil {
+					log.Printf("warn: gh team usage %s: %v", id, err)
+					continue
+				}
+				mu.Lock()
+				emitted++
+				mu.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+	log.Printf("gh team usage: %d entities across %d team(s) in %s", emitted, len(teams), org.Name)
+}
+
+// portTeamID is the Port _team identifier for a GitHub team, per
+// GITHUB_TEAM_RELATION_KEY; "" leaves team_usage unset.
+func portTeamID(cfg config.Config, t githubapi.Team) string {
+	switch cfg.GitHubTeamRelationKey {
+	case "slug":
+		return t.Slug
+	case "none":
+		return ""
+	}
+	return t.Name
+}
+
+// GitHubEnterpriseUsage ingests one github_copilot_usage entity per day of
+// enterprise-wide metrics.
+func GitHubEnterpriseUsage(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client) {
+	ent := cfg.GitHubEnterprise
+	since := time.Now().UTC().AddDate(0, 0, -cfg.GitHubMetricsDays)
+	days, err := githubapi.FetchEnterpriseMetrics(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, cfg.GitHubEnterpriseToken, ent, since)
+	if err != nil {
+		log.Printf("warn: gh enterprise metrics: %v", err)
+		return
+	}
+	breakdowns := 0
+	for _, d := range days {
+		props := usageProps(d, "", "")
+		props["git_hub_enterprise"] = ent
+		id := "enterprise/" + ent + "@" + d.Date
+		if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookUsageURL, "gh-copilot-usage", "github_copilot_usage",
+			id, props, nil); err != nil {
+			log.Printf("warn: gh enterprise usage %s: %v", d.Date, err)
+			continue
+		}
+		if cfg.EnableGitHubUsageBreakdown {
+			breakdowns += usageBreakdown(ctx, cfg, hc, pcli, d, id, "enterprise/"+ent, map[string]any{"git_hub_enterprise": ent})
+		}
+	}
+	log.Printf("gh enterprise usage: %d day(s), %d breakdown row(s) for %s", len(days), breakdowns, ent)
+}
+
+func usageProps(d githubapi.DayMetrics, org, team string) map[string]any {
+	t := d.Totals()
+	props := map[string]any{
+		"record_date":             d.Date + "T00:00:00Z",
+		"breakdown":               d.Raw,
+		"total_suggestions_count": t.Suggestions,
+		"total_acceptances_count": t.Acceptances,
+		"total_lines_suggested":   t.LinesSuggested,
+		"total_lines_accepted":    t.LinesAccepted,
+		"total_active_users":      t.ActiveUsers,
+		"total_chat_acceptances":  t.ChatAcceptances,
+		"total_chat_turns":        t.ChatTurns,
+		"total_active_chat_users": t.ActiveChatUsers,
+	}
+	if org != "" {
+		props["git_hub_org"] = org
+	}
+	if team != "" {
+		props["git_hub_team"] = team
+	}
+	if t.EditorTop != "" {
+		props["editor_top"] = t.EditorTop
+	}
+	if t.LanguageTop != "" {
+		props["language_top"] = t.LanguageTop
+	}
+	return props
+}
@@ -10,27 +10,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
 )
 
-func periodToken(days int) string {
-	switch {
-	case days <= 7:
-		return "D7"
-	case days <= 30:
-		return "D30"
-	case days <= 90:
-		return "D90"
-	case days <= 180:
-		return "D180"
-	default:
-		return "ALL"
-	}
-}
-
 func sha256Hex(s string) string {
 	h := sha256.Sum256([]byte(s))
 	return hex.EncodeToString(h[:])
@@ -43,6 +33,33 @@ func str(v any) string {
 	return ""
 }
 
+// reportDateLayouts are the date forms Graph reports use: yyyy-mm-dd in
+// CSV, and ISO timestamps with or without a zone in JSON.
+var reportDateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+// rfc3339Date normalizes a report date to RFC3339 (UTC); ok is false for
+// empty or unparseable values.
+func rfc3339Date(v any) (string, bool) {
+	s := strings.TrimSpace(str(v))
+	if s == "" {
+		return "", false
+	}
+	for _, layout := range reportDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format(time.RFC3339), true
+		}
+	}
+	return "", false
+}
+
+// setDate sets props[key] to v as RFC3339, leaving key out when v is empty
+// or unparseable so Port never receives an invalid date-time.
+func setDate(props map[string]any, key string, v any) {
+	if d, ok := rfc3339Date(v); ok {
+		props[key] = d
+	}
+}
+
 func intFrom(m map[string]any, key string) int {
 	if f, ok := m[key].(float64); ok {
 		return int(f)
@@ -82,6 +99,41 @@ func postWebhook(ctx context.Context, hc httpx.Doer, urlStr, secret string, payl
 	return nil
 }
 
+// upsertEntity sends one entity to Port: to the webhook at hookURL when
+// webhooks are enabled (the mapping reads .body.identifier/.body.record),
+// otherwise straight to the entities API.
+func upsertEntity(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, hookURL, kind, blueprint, identifier string, props, rels map[string]any) error {
+	if cfg.UseWebhook {
+		payload := map[string]any{"kind": kind, "identifier": identifier, "record": props}
+		if len(rels) > 0 {
+			payload["relations"] = rels
+		}
+		return postWebhook(ctx, hc, hookURL, cfg.WebhookSecret, payload)
+	}
+	ent := map[string]any{"identifier": identifier, "properties": props}
+	if len(rels) > 0 {
+		ent["relations"] = rels
+	}
+	return pcli.UpsertEntity(ctx, blueprint, ent)
+}
+
+// forEachOrg runs fn for every configured GitHub org, at most
+// cfg.GitHubConcurrency at a time, and waits for all of them.
+func forEachOrg(cfg config.Config, fn func(org config.GitHubOrg)) {
+	sem := make(chan struct{}, cfg.GitHubConcurrency)
+	var wg sync.WaitGroup
+	for _, org := range cfg.GitHubOrgs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(org config.GitHubOrg) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(org)
+		}(org)
+	}
+	wg.Wait()
+}
+
 func signBodySHA256(secret string, body []byte) string {
 	if secret == "" {
 		return ""
@@ -109,3 +161,17 @@ func stableMapFingerprint(m map[string]any) string {
 	}
 	return b.String()
 }
+
+// writeStateFile stores v as JSON at path, via a temp file and rename so a
+// crash never leaves a half-written state for the next run.
+func writeStateFile(path string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,51 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// orgEmails returns the login -> email map for org when
+// GITHUB_RESOLVE_EMAILS is on (SAML identities, then verified domain
+// emails), or nil. Callers resolve once per org and pass the map down.
+func orgEmails(ctx context.Context, cfg config.Config, hc httpx.Doer, org, token string) map[string]string {
+	if !cfg.GitHubResolveEmails {
+		return nil
+	}
+	emails, err := githubapi.ResolveEmails(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, token, org)
+	if err != nil {
+		// One source failing still leaves the other's matches in emails.
+		log.Printf("warn: gh identities %s: %v", org, err)
+	}
+	log.Printf("gh identities: %d login(s) resolved to emails in %s", len(emails), org)
+	return emails
+}
+
+// seatEmail picks the Port user email for a seat: the resolved corporate
+// email, else the assignee's public email.
+func seatEmail(s githubapi.Seat, emails map[string]string) string {
+	if s.Assignee == nil {
+		return ""
+	}
+	if e := emails[strings.ToLower(s.Login())]; e != "" {
+		return e
+	}
+	return s.Assignee.Email
+}
+
+// logUnmatched lists seat holders without an email so they can be fixed in
+// the IdP or mapped by hand.
+func logUnmatched(org string, logins []string) {
+	if len(logins) == 0 {
+		return
+	}
+	sort.Strings(logins)
+	log.Printf("warn: gh identities %s: %d seat holder(s) without a Port user email: %s",
+		org, len(logins), strings.Join(logins, ", "))
+}
@@ -0,0 +1,31 @@
+package ingest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+)
+
+func TestOrgEmailsKeepsMatchesWhenOneSourceFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "samlIdentityProvider") {
+			_, _ = io.WriteString(w, `{"errors":[{"message":"Resource not accessible by integration"}]}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"organization":{"membersWithRole":{
+			"pageInfo":{"hasNextPage":false},
+			"nodes":[{"login":"Ann","organizationVerifiedDomainEmails":["Ann@Example.com"]},{"login":"bob","organizationVerifiedDomainEmails":[]}]}}}}`)
+	}))
+	defer srv.Close()
+	cfg := config.Config{GitHubAPIBase: srv.URL, GitHubResolveEmails: true}
+	got := orgEmails(context.Background(), cfg, srv.Client(), "acme", "tok")
+	if len(got) != 1 || got["ann"] != "ann@example.com" {
+		t.Errorf("orgEmails = %v, want the verified-domain match for ann", got)
+	}
+}
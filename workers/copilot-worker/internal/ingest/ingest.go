@@ -2,24 +2,124 @@ package ingest
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
 	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/tokensource"
 	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
 	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
 	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/graphapi"
 )
 
-// GitHubSeats ingests GitHub Copilot seat snapshots via webhook or Port API.
+// GitHubSeats ingests one GitHub Copilot seat snapshot per org (org@date),
+// walking orgs concurrently, plus an optional cross-org total (all@date).
+// With GITHUB_ENTERPRISE set it snapshots the enterprise instead, plus one
+// rollup per org the seats were assigned through. With
+// INGEST_GITHUB_SEAT_EVENTS it also records seat changes since the last run.
 func GitHubSeats(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, recordDate string) {
-	seats, err := githubapi.FetchSeats(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, cfg.GitHubToken, cfg.GitHubOrg)
+	if cfg.GitHubEnterprise != "" {
+		gitHubEnterpriseSeats(ctx, cfg, hc, pcli, recordDate)
+		return
+	}
+	var tracker *seatTracker
+	if cfg.EnableGitHubSeatEvents {
+		tracker = newSeatTracker(ctx, cfg, pcli, false)
+	}
+	var (
+		mu     sync.Mutex
+		all    []githubapi.Seat
+		failed int
+	)
+	forEachOrg(cfg, func(org config.GitHubOrg) {
+		seats, err := githubapi.FetchSeats(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name)
+		if err != nil {
+			log.Printf("warn: gh seats %s: %v", org.Name, err)
+			mu.Lock()
+			failed++
+			mu.Unlock()
+			return
+		}
+		snapshotID := org.Name + "@" + recordDate
+		props := seatsProps(cfg, seats, recordDate)
+		props["git_hub_org"] = org.Name
+		if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-seats", "github_copilot_seats",
+			snapshotID, props, nil); err != nil {
+			log.Printf("warn: seats upsert %s: %v", org.Name, err)
+		}
+		var failedSeats map[string]bool
+		if cfg.EnableGitHubSeatDetails {
+			failedSeats = gitHubSeatEntities(ctx, cfg, hc, pcli, seats, org.Name, orgEmails(ctx, cfg, hc, org.Name, org.Token), snapshotID, recordDate)
+			markSeatDetailsComplete(ctx, cfg, hc, pcli, snapshotID, props, failedSeats)
+		}
+		tracker.observe(org.Name, seats, failedSeats)
+		mu.Lock()
+		all = append(all, seats...)
+		mu.Unlock()
+	})
+	tracker.flush(ctx, cfg, hc, pcli, recordDate)
+	if !cfg.GitHubSeatsTotal || len(cfg.GitHubOrgs) < 2 {
+		return
+	}
+	if failed > 0 {
+		log.Printf("warn: gh seats total skipped: %d of %d org(s) failed", failed, len(cfg.GitHubOrgs))
+		return
+	}
+	if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-seats", "github_copilot_seats",
+		"all@"+recordDate, seatsProps(cfg, all, recordDate), nil); err != nil {
+		log.Printf("warn: seats total upsert: %v", err)
+	}
+}
+
+func gitHubEnterpriseSeats(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, recordDate string) {
+	ent := cfg.GitHubEnterprise
+	var tracker *seatTracker
+	if cfg.EnableGitHubSeatEvents {
+		tracker = newSeatTracker(ctx, cfg, pcli, true)
+	}
+	seats, err := githubapi.FetchEnterpriseSeats(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, cfg.GitHubEnterpriseToken, ent)
 	if err != nil {
-		log.Printf("warn: gh seats: %v", err)
+		log.Printf("warn: gh enterprise seats: %v", err)
 		return
 	}
-	seatsTotal := len(seats)
+	props := seatsProps(cfg, seats, recordDate)
+	props["git_hub_enterprise"] = ent
+	if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-seats", "github_copilot_seats",
+		"enterprise/"+ent+"@"+recordDate, props, nil); err != nil {
+		log.Printf("warn: enterprise seats upsert: %v", err)
+	}
+
+	byOrg := map[string][]githubapi.Seat{}
+	for _, s := range seats {
+		byOrg[s.Org()] = append(byOrg[s.Org()], s)
+	}
+	for org, orgSeats := range byOrg {
+		if org == "" {
+			log.Printf("warn: gh enterprise seats: %d seat(s) without an org", len(orgSeats))
+			continue
+		}
+		props := seatsProps(cfg, orgSeats, recordDate)
+		props["git_hub_enterprise"] = ent
+		props["git_hub_org"] = org
+		if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-seats", "github_copilot_seats",
+			org+"@"+recordDate, props, nil); err != nil {
+			log.Printf("warn: org seats rollup %s: %v", org, err)
+		}
+		var failedSeats map[string]bool
+		if cfg.EnableGitHubSeatDetails {
+			failedSeats = gitHubSeatEntities(ctx, cfg, hc, pcli, orgSeats, org, orgEmails(ctx, cfg, hc, org, cfg.GitHubToken), org+"@"+recordDate, recordDate)
+			markSeatDetailsComplete(ctx, cfg, hc, pcli, org+"@"+recordDate, props, failedSeats)
+		}
+		tracker.observe(org, orgSeats, failedSeats)
+	}
+	tracker.flush(ctx, cfg, hc, pcli, recordDate)
+}
+
+func seatsProps(cfg config.Config, seats []githubapi.Seat, recordDate string) map[string]any {
 	cut14 := time.Now().AddDate(0, 0, -cfg.SeatsActiveD14)
 	cut30 := time.Now().AddDate(0, 0, -30)
 	var seatsActive14, seatsActive30 int
@@ -33,60 +133,48 @@ func GitHubSeats(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *po
 			}
 		}
 	}
-	if cfg.UseWebhook {
-		payload := map[string]any{
-			"kind": "gh-copilot-seats",
-			"record": map[string]any{
-				"record_date":      recordDate,
-				"seats_total":      seatsTotal,
-				"seats_active_14d": seatsActive14,
-				"seats_active_30d": seatsActive30,
-			},
-		}
-		if err := postWebhook(ctx, hc, cfg.WebhookSeatsURL, cfg.WebhookSecret, payload); err != nil {
-			log.Printf("warn: seats webhook: %v", err)
-		}
-		return
+	props := map[string]any{
+		"record_date":      recordDate,
+		"seats_total":      len(seats),
+		"seats_active_14d": seatsActive14,
+		"seats_active_30d": seatsActive30,
 	}
-
-	ent := map[string]any{
-		"identifier": recordDate,
-		"properties": map[string]any{
-			"record_date":      recordDate,
-			"seats_total":      seatsTotal,
-			"seats_active_14d": seatsActive14,
-			"seats_active_30d": seatsActive30,
-		},
-	}
-	if err := pcli.UpsertEntity(ctx, "github_copilot_seats", ent); err != nil {
-		log.Printf("warn: seats upsert: %v", err)
+	for k, v := range seatCosts(cfg, seats, time.Now()) {
+		props[k] = v
 	}
+	return props
 }
 
-// M365 ingests Microsoft 365 Copilot summary + user details.
+// M365 ingests Microsoft 365 Copilot summary + user details for every
+// period in M365_PERIODS, plus the daily trend with INGEST_M365_TREND.
 func M365(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, recordDate string) {
-	period := periodToken(cfg.PeriodDays)
-	gTok, err := graphapi.Token(ctx, hc, cfg.MSTenantID, cfg.MSClientID, cfg.MSClientSecret)
+	creds, err := graphCredentials(cfg)
 	if err != nil {
+		log.Fatalf("graph credentials: %v", err)
+	}
+	// Tokens come from a cached source so long runs refresh them; the first
+	// one is fetched up front to fail fast on bad credentials.
+	src := graphapi.NewTokenSource(hc, creds)
+	if _, err := src.Token(ctx); err != nil {
 		log.Fatalf("graph token: %v", err)
 	}
-
-	summary, err := graphapi.CopilotSummary(ctx, hc, cfg.GraphAPIBase, gTok, period)
+	gu, err := url.Parse(cfg.GraphAPIBase)
 	if err != nil {
-		log.Fatalf("graph summary: %v", err)
+		log.Fatalf("graph api base: %v", err)
 	}
-	enabled := intFrom(summary, "enabledUserCount")
-	active := intFrom(summary, "activeUserCount")
+	gc := tokensource.NewDoer(hc, src, gu.Host)
 
 	var skuTotal int
+	copilotSkus := map[string]string{}
 	if len(cfg.M365Skus) > 0 {
-		skus, err := graphapi.SubscribedSkus(ctx, hc, cfg.GraphAPIBase, gTok)
+		skus, err := graphapi.SubscribedSkus(ctx, gc, cfg.GraphAPIBase)
 		if err != nil {
 			log.Printf("warn: graph skus: %v", err)
 		} else {
 			for _, v := range skus {
 				part := str(v["skuPartNumber"])
 				if containsFold(cfg.M365Skus, part) {
+					copilotSkus[str(v["skuId"])] = part
 					if pu, ok := v["prepaidUnits"].(map[string]any); ok {
 						if en, ok2 := pu["enabled"].(float64); ok2 {
 							skuTotal += int(en)
@@ -97,88 +185,105 @@ func M365(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.C
 		}
 	}
 
+	var activity *m365Activity
+	if cfg.EnableM365Licenses {
+		activity = &m365Activity{last: map[string]string{}}
+	}
+	var dir *m365Directory
+	if cfg.EnableM365Directory {
+		dir = newM365Directory(cfg, gc, recordDate)
+	}
+	for _, period := range cfg.M365Periods {
+		m365Period(ctx, cfg, hc, gc, pcli, period, skuTotal, recordDate, activity, dir)
+	}
+	if dir != nil {
+		dir.rollups(ctx, cfg, hc, pcli, recordDate)
+	}
+	if cfg.EnableM365Trend {
+		m365Trend(ctx, cfg, hc, gc, pcli)
+	}
+	if cfg.EnableM365Licenses {
+		m365Licenses(ctx, cfg, hc, gc, pcli, copilotSkus, activity, recordDate)
+	}
+}
+
+// m365Period ingests one report period: an m365_copilot_usage_summary
+// (period@date) and one m365_copilot_user per user and period. gc carries
+// the Graph token; hc is used for Port. activity (may be nil) collects
+// last activity per UPN; dir (may be nil) adds directory fields.
+func m365Period(ctx context.Context, cfg config.Config, hc, gc httpx.Doer, pcli *portapi.Client, period string, skuTotal int, recordDate string, activity *m365Activity, dir *m365Directory) {
+	summary, err := graphapi.CopilotSummary(ctx, gc, cfg.GraphAPIBase, period)
+	if err != nil {
+		log.Fatalf("graph summary %s: %v", period, err)
+	}
+	enabled := intFrom(summary, "enabledUserCount")
+	active := intFrom(summary, "activeUserCount")
+
+	props := map[string]any{
+		"period":             period,
+		"report_date":        recordDate,
+		"enabled_user_count": enabled,
+		"active_user_count":  active,
+		"sku_total":          skuTotal,
+	}
+	setDate(props, "report_refresh_date", summary["reportRefreshDate"])
+
 	if cfg.UseWebhook {
 		payload := map[string]any{
-			"kind": "m365-copilot-summary",
-			"record": map[string]any{
-				"period":             period,
-				"report_date":        recordDate,
-				"enabled_user_count": enabled,
-				"active_user_count":  active,
-				"sku_total":          skuTotal,
-			},
+			"kind":   "m365-copilot-summary",
+			"record": props,
 		}
 		if err := postWebhook(ctx, hc, cfg.WebhookM365SumURL, cfg.WebhookSecret, payload); err != nil {
-			log.Printf("warn: m365 summary webhook: %v", err)
+			log.Printf("warn: m365 summary webhook %s: %v", period, err)
 		}
 	} else {
 		ent := map[string]any{
 			"identifier": period + "@" + recordDate,
-			"properties": map[string]any{
-				"period":             period,
-				"report_date":        recordDate,
-				"enabled_user_count": enabled,
-				"active_user_count":  active,
-				"sku_total":          skuTotal,
-			},
+			"properties": props,
 		}
 		if err := pcli.UpsertEntity(ctx, "m365_copilot_usage_summary", ent); err != nil {
-			log.Printf("warn: m365 summary upsert: %v", err)
+			log.Printf("warn: m365 summary upsert %s: %v", period, err)
 		}
 	}
 
-	users, err := graphapi.CopilotUserDetail(ctx, hc, cfg.GraphAPIBase, gTok, period)
-	if err != nil {
-		log.Printf("warn: graph user detail: %v", err)
-		users = nil
-	}
-	const maxUsersPerRun = 5000
-	count := 0
-	for _, u := range users {
-		if count >= maxUsersPerRun {
-			break
-		}
-		upn := str(u["userPrincipalName"])
-		hash := upn
-		if hash == "" {
-			if dn := str(u["displayName"]); dn != "" {
-				hash = dn
-			} else {
-				hash = stableMapFingerprint(u)
-			}
-		}
-		userProps := map[string]any{
-			"period":                        period,
-			"report_date":                   recordDate,
-			"user_principal_name":           upn,
-			"user_hash":                     sha256Hex(hash),
-			"last_activity_date":            u["lastActivityDate"],
-			"teams_copilot_last_activity":   u["microsoftTeamsCopilotLastActivityDate"],
-			"word_copilot_last_activity":    u["wordCopilotLastActivityDate"],
-			"excel_copilot_last_activity":   u["excelCopilotLastActivityDate"],
-			"ppt_copilot_last_activity":     u["powerPointCopilotLastActivityDate"],
-			"outlook_copilot_last_activity": u["outlookCopilotLastActivityDate"],
-			"onenote_copilot_last_activity": u["oneNoteCopilotLastActivityDate"],
-			"loop_copilot_last_activity":    u["loopCopilotLastActivityDate"],
-			"chat_last_activity":            u["copilotChatLastActivityDate"],
-		}
-		if cfg.UseWebhook {
-			payload := map[string]any{"kind": "m365-copilot-users", "user": userProps}
-			if err := postWebhook(ctx, hc, cfg.WebhookM365UsrURL, cfg.WebhookSecret, payload); err != nil {
-				log.Printf("warn: m365 users webhook: %v", err)
-			}
-		} else {
-			ent := map[string]any{
-				"identifier": userProps["user_hash"],
-				"properties": userProps,
-			}
-			if err := pcli.UpsertEntity(ctx, "m365_copilot_user", ent); err != nil {
-				log.Printf("warn: m365 user upsert: %v", err)
-			}
-		}
-		count++
+	sink := newM365UserSink(ctx, cfg, hc, pcli, "m365-copilot-users", "m365_copilot_user", cfg.M365UserLimit, m365UserID)
+	if dir != nil {
+		sink.enrich = dir.enrich
+	}
+	err = graphapi.CopilotUserDetail(ctx, gc, cfg.GraphAPIBase, period, func(u map[string]any) error {
+		activity.observe(u)
+		return sink.add(m365UserProps(period, recordDate, u))
+	})
+	sent, failed := sink.close()
+	if err != nil && activity != nil {
+		activity.incomplete = true
+	}
+	switch {
+	case errors.Is(err, errUserLimit):
+		log.Printf("m365 users %s: stopped at M365_USER_LIMIT=%d", period, cfg.M365UserLimit)
+	case err != nil:
+		log.Printf("warn: graph user detail %s: %v", period, err)
+	}
+	log.Printf("m365 users %s: %d sent, %d failed", period, sent, failed)
+}
+
+// graphCredentials builds the Graph app credentials from config, parsing the
+// certificate PEM when one is configured.
+func graphCredentials(cfg config.Config) (graphapi.Credentials, error) {
+	c := graphapi.Credentials{
+		AuthorityHost:      cfg.GraphAuthorityHost,
+		Scope:              cfg.GraphScope,
+		TenantID:           cfg.MSTenantID,
+		ClientID:           cfg.MSClientID,
+		ClientSecret:       cfg.MSClientSecret,
+		FederatedTokenFile: cfg.MSFederatedTokenFile,
 	}
-	if len(users) > maxUsersPerRun {
-		log.Printf("warn: m365 user detail truncated: processed %d of %d rows", maxUsersPerRun, len(users))
+	if cfg.MSClientCertificate != "" {
+		cert, key, err := graphapi.LoadCertificate([]byte(cfg.MSClientCertificate), []byte(cfg.MSClientKey))
+		if err != nil {
+			return c, err
+		}
+		c.Certificate, c.Key = cert, key
 	}
+	return c, nil
 }
@@ -0,0 +1,162 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/graphapi"
+)
+
+// noDepartment groups users whose directory entry has no department.
+const noDepartment = "Unassigned"
+
+// m365PeriodDays is the activity window of each report period; 0 (ALL)
+// counts any activity.
+var m365PeriodDays = map[string]int{"D7": 7, "D30": 30, "D90": 90, "D180": 180, "ALL": 0}
+
+// m365Directory adds Entra ID profile fields to m365_copilot_user rows as
+// the user sink sends them, and tallies users per period and department
+// for the m365_copilot_department rollups. Profiles are cached across
+// periods so each UPN is looked up once per run.
+type m365Directory struct {
+	cfg config.Config
+	gc  httpx.Doer
+	now time.Time
+
+	mu       sync.Mutex
+	profiles map[string]*graphapi.Profile
+	depts    map[string]map[string]*deptStats
+}
+
+type deptStats struct {
+	users  int
+	active int
+}
+
+func newM365Directory(cfg config.Config, gc httpx.Doer, recordDate string) *m365Directory {
+	now, err := time.Parse(time.RFC3339, recordDate)
+	if err != nil {
+		now = time.Now().UTC()
+	}
+	return &m365Directory{
+		cfg:      cfg,
+		gc:       gc,
+		now:      now,
+		profiles: map[string]*graphapi.Profile{},
+		depts:    map[string]map[string]*deptStats{},
+	}
+}
+
+// enrich sets department, job_title, office_location, company_name,
+// manager and manager_upn on each row and counts it for its department.
+// It runs on the sink's workers, so several batches may enrich at once.
+func (d *m365Directory) enrich(ctx context.Context, batch []map[string]any) {
+	var missing []string
+	d.mu.Lock()
+	for _, props := range batch {
+		upn := strings.ToLower(str(props["user_principal_name"]))
+		if _, ok := d.profiles[upn]; upn != "" && !ok {
+			missing = append(missing, upn)
+		}
+	}
+	d.mu.Unlock()
+	if len(missing) > 0 {
+		found, err := graphapi.DirectoryProfiles(ctx, d.gc, d.cfg.GraphAPIBase, missing)
+		if err != nil {
+			log.Printf("warn: m365 directory lookup of %d user(s): %v", len(missing), err)
+		}
+		d.mu.Lock()
+		for _, upn := range missing {
+			if p, ok := found[upn]; ok {
+				d.profiles[upn] = &p
+			} else if err == nil {
+				// Not in the directory (or concealed); don't ask again.
+				d.profiles[upn] = nil
+			}
+		}
+		d.mu.Unlock()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, props := range batch {
+		dept := noDepartment
+		if p := d.profiles[strings.ToLower(str(props["user_principal_name"]))]; p != nil {
+			props["department"] = p.Department
+			props["job_title"] = p.JobTitle
+			props["office_location"] = p.OfficeLocation
+			props["company_name"] = p.CompanyName
+			props["manager"] = p.ManagerName
+			props["manager_upn"] = p.ManagerUPN
+			if p.Department != "" {
+				dept = p.Department
+			}
+		}
+		period := str(props["period"])
+		if d.depts[period] == nil {
+			d.depts[period] = map[string]*deptStats{}
+		}
+		st := d.depts[period][dept]
+		if st == nil {
+			st = &deptStats{}
+			d.depts[period][dept] = st
+		}
+		st.users++
+		if d.activeIn(str(props["last_activity_date"]), m365PeriodDays[period]) {
+			st.active++
+		}
+	}
+}
+
+// activeIn reports whether the last activity date (yyyy-mm-dd or RFC3339)
+// falls within days of the run; days 0 accepts any activity.
+func (d *m365Directory) activeIn(last string, days int) bool {
+	if len(last) < 10 {
+		return false
+	}
+	t, err := time.Parse("2006-01-02", last[:10])
+	if err != nil {
+		return false
+	}
+	return days == 0 || !t.Before(d.now.AddDate(0, 0, -days))
+}
+
+// rollups upserts one m365_copilot_department per period and department
+// (period/department-slug@run).
+func (d *m365Directory) rollups(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, recordDate string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	count := 0
+	for period, depts := range d.depts {
+		names := make([]string, 0, len(depts))
+		for name := range depts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			st := depts[name]
+			props := map[string]any{
+				"record_date":  recordDate,
+				"period":       period,
+				"department":   name,
+				"users":        st.users,
+				"active_users": st.active,
+			}
+			id := period + "/" + idSlug(name) + "@" + recordDate
+			if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookM365SumURL, "m365-copilot-department", "m365_copilot_department",
+				id, props, nil); err != nil {
+				log.Printf("warn: m365 department %s: %v", id, err)
+				continue
+			}
+			count++
+		}
+	}
+	log.Printf("m365 departments: %d rollup(s)", count)
+}
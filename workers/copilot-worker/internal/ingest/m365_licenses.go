@@ -0,0 +1,105 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/graphapi"
+)
+
+// m365Activity records each user detail row's last activity date by
+// lower-cased UPN while the report streams, for the licensed-never-used
+// join. incomplete marks a run where some user detail was not read.
+type m365Activity struct {
+	last       map[string]string
+	incomplete bool
+}
+
+func (a *m365Activity) observe(u map[string]any) {
+	if a == nil {
+		return
+	}
+	upn := strings.ToLower(str(u["userPrincipalName"]))
+	if upn == "" {
+		return
+	}
+	if la := str(u["lastActivityDate"]); la > a.last[upn] || a.last[upn] == "" {
+		a.last[upn] = la
+	}
+}
+
+// m365Licenses upserts one m365_copilot_license per user holding a Copilot
+// SKU (skus maps skuId to skuPartNumber), flagging never_active when the
+// user detail report has no Copilot activity for the UPN. Every licensed
+// user is sent so the flag clears once someone starts using Copilot.
+func m365Licenses(ctx context.Context, cfg config.Config, hc, gc httpx.Doer, pcli *portapi.Client, skus map[string]string, activity *m365Activity, recordDate string) {
+	if activity.incomplete {
+		log.Printf("warn: m365 licenses: user detail was not fully read; skipping never-active flags")
+		return
+	}
+	if len(skus) == 0 {
+		log.Printf("warn: m365 licenses: no subscribed SKU matches M365_COPILOT_SKUS")
+		return
+	}
+	ids := make([]string, 0, len(skus))
+	for id := range skus {
+		ids = append(ids, id)
+	}
+	users, err := graphapi.LicensedUsers(ctx, gc, cfg.GraphAPIBase, ids)
+	if err != nil {
+		log.Printf("warn: m365 licenses: %v", err)
+		return
+	}
+	matched := 0
+	for _, u := range users {
+		if _, ok := activity.last[strings.ToLower(u.UserPrincipalName)]; ok {
+			matched++
+		}
+	}
+	// Concealed user names in the reports cannot be joined on UPN.
+	if len(activity.last) > 0 && len(users) > 0 && matched == 0 {
+		log.Printf("warn: m365 licenses: no licensed UPN appears in user detail (report names concealed?); skipping never-active flags")
+		return
+	}
+
+	now, _ := time.Parse(time.RFC3339, recordDate)
+	sink := newM365UserSink(ctx, cfg, hc, pcli, "m365-copilot-licenses", "m365_copilot_license", 0,
+		func(props map[string]any) string { return str(props["user_hash"]) })
+	never := 0
+	for _, u := range users {
+		last := activity.last[strings.ToLower(u.UserPrincipalName)]
+		parts := make([]string, 0, len(u.SkuIDs))
+		for _, id := range u.SkuIDs {
+			parts = append(parts, skus[id])
+		}
+		props := map[string]any{
+			"record_date":               recordDate,
+			"user_principal_name":       u.UserPrincipalName,
+			"user_hash":                 sha256Hex(u.UserPrincipalName),
+			"display_name":              u.DisplayName,
+			"sku_part_numbers":          parts,
+			"never_active":              last == "",
+			"last_activity_date":        nil,
+			"license_updated_at":        nil,
+			"days_since_license_update": nil,
+		}
+		if d, ok := rfc3339Date(last); ok {
+			props["last_activity_date"] = d
+		}
+		if at, err := time.Parse(time.RFC3339, u.LicenseUpdatedAt); err == nil {
+			props["license_updated_at"] = at.UTC().Format(time.RFC3339)
+			props["days_since_license_update"] = int(now.Sub(at).Hours() / 24)
+		}
+		if last == "" {
+			never++
+		}
+		_ = sink.add(props)
+	}
+	sent, failed := sink.close()
+	log.Printf("m365 licenses: %d licensed user(s), %d never active (%d sent, %d failed)", len(users), never, sent, failed)
+}
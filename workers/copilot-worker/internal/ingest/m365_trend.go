@@ -0,0 +1,60 @@
+package ingest
+
+import (
+	"context"
+	"log"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/graphapi"
+)
+
+// m365PeriodOrder ranks Graph report periods by length.
+var m365PeriodOrder = map[string]int{"D7": 1, "D30": 2, "D90": 3, "D180": 4, "ALL": 5}
+
+// m365Trend upserts one m365_copilot_usage_trend per day of the user count
+// trend, keyed by the day alone, so every run re-sends the whole window and
+// days a missed run skipped are filled in. It reads the longest period in
+// M365_PERIODS.
+func m365Trend(ctx context.Context, cfg config.Config, hc, gc httpx.Doer, pcli *portapi.Client) {
+	period := cfg.M365Periods[0]
+	for _, p := range cfg.M365Periods {
+		if m365PeriodOrder[p] > m365PeriodOrder[period] {
+			period = p
+		}
+	}
+	days, err := graphapi.CopilotUserCountTrend(ctx, gc, cfg.GraphAPIBase, period)
+	if err != nil {
+		log.Printf("warn: graph trend %s: %v", period, err)
+		return
+	}
+	for _, d := range days {
+		reportDate, ok := rfc3339Date(d.ReportDate)
+		if !ok {
+			log.Printf("warn: m365 trend: skipping row with report date %q", d.ReportDate)
+			continue
+		}
+		day := reportDate[:10]
+		props := map[string]any{
+			"report_date":               reportDate,
+			"period":                    period,
+			"enabled_users":             d.EnabledUsers,
+			"active_users":              d.ActiveUsers,
+			"teams_active_users":        d.TeamsActive,
+			"word_active_users":         d.WordActive,
+			"excel_active_users":        d.ExcelActive,
+			"powerpoint_active_users":   d.PowerPointActive,
+			"outlook_active_users":      d.OutlookActive,
+			"onenote_active_users":      d.OneNoteActive,
+			"loop_active_users":         d.LoopActive,
+			"copilot_chat_active_users": d.CopilotChatActive,
+		}
+		setDate(props, "report_refresh_date", d.ReportRefreshDate)
+		if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookM365SumURL, "m365-copilot-trend", "m365_copilot_usage_trend",
+			day, props, nil); err != nil {
+			log.Printf("warn: m365 trend %s: %v", day, err)
+		}
+	}
+	log.Printf("m365 trend %s: %d day(s)", period, len(days))
+}
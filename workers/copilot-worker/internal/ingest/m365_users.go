@@ -0,0 +1,174 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+)
+
+// errUserLimit stops the user detail walk once M365_USER_LIMIT users are queued.
+var errUserLimit = errors.New("m365 user limit reached")
+
+// m365UserSink batches per-user entities (m365_copilot_user rows as they
+// are decoded, m365_copilot_license) and sends the batches from
+// cfg.M365UserConcurrency workers, so the report is never held in memory
+// and large tenants finish within RUN_TIMEOUT. Webhook batches go out as
+// one {"kind": kind, "users": [...]} payload to the users webhook; API
+// batches use Port's bulk upsert.
+type m365UserSink struct {
+	ctx       context.Context
+	cfg       config.Config
+	hc        httpx.Doer
+	pcli      *portapi.Client
+	kind      string
+	blueprint string
+	limit     int
+	identify  func(props map[string]any) string
+	// enrich, when set before the first add, amends each batch before it
+	// is sent (directory fields).
+	enrich  func(ctx context.Context, batch []map[string]any)
+	batches chan []map[string]any
+	wg      sync.WaitGroup
+
+	batch  []map[string]any
+	queued int
+
+	mu     sync.Mutex
+	sent   int
+	failed int
+}
+
+// newM365UserSink starts the senders; limit > 0 caps the users queued and
+// identify gives each entity's identifier in API mode.
+func newM365UserSink(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, kind, blueprint string, limit int, identify func(map[string]any) string) *m365UserSink {
+	s := &m365UserSink{
+		ctx: ctx, cfg: cfg, hc: hc, pcli: pcli,
+		kind: kind, blueprint: blueprint, limit: limit, identify: identify,
+		batches: make(chan []map[string]any),
+	}
+	for i := 0; i < cfg.M365UserConcurrency; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for b := range s.batches {
+				s.send(b)
+			}
+		}()
+	}
+	return s
+}
+
+// add queues one user; it returns errUserLimit once the limit is reached.
+// It is called from a single goroutine (the report decoder).
+func (s *m365UserSink) add(props map[string]any) error {
+	if s.limit > 0 && s.queued >= s.limit {
+		return errUserLimit
+	}
+	s.batch = append(s.batch, props)
+	s.queued++
+	if len(s.batch) >= s.cfg.M365UserBatchSize {
+		s.flush()
+	}
+	if s.limit > 0 && s.queued >= s.limit {
+		return errUserLimit
+	}
+	return nil
+}
+
+func (s *m365UserSink) flush() {
+	if len(s.batch) == 0 {
+		return
+	}
+	select {
+	case s.batches <- s.batch:
+	case <-s.ctx.Done():
+		s.mu.Lock()
+		s.failed += len(s.batch)
+		s.mu.Unlock()
+	}
+	s.batch = nil
+}
+
+// close sends the last partial batch, waits for the workers and returns
+// how many users were sent and how many failed.
+func (s *m365UserSink) close() (sent, failed int) {
+	s.flush()
+	close(s.batches)
+	s.wg.Wait()
+	return s.sent, s.failed
+}
+
+func (s *m365UserSink) send(batch []map[string]any) {
+	if s.enrich != nil {
+		s.enrich(s.ctx, batch)
+	}
+	var err error
+	if s.cfg.UseWebhook {
+		payload := map[string]any{"kind": s.kind, "users": batch}
+		err = postWebhook(s.ctx, s.hc, s.cfg.WebhookM365UsrURL, s.cfg.WebhookSecret, payload)
+	} else {
+		ents := make([]any, 0, len(batch))
+		for _, props := range batch {
+			ents = append(ents, map[string]any{"identifier": s.identify(props), "properties": props})
+		}
+		err = s.pcli.BulkUpsertEntities(s.ctx, s.blueprint, ents)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		log.Printf("warn: %s batch of %d: %v", s.blueprint, len(batch), err)
+		s.failed += len(batch)
+		return
+	}
+	s.sent += len(batch)
+}
+
+// m365UserID keys m365_copilot_user by user and period so periods do not
+// overwrite each other.
+func m365UserID(props map[string]any) string {
+	return str(props["user_hash"]) + "@" + str(props["period"])
+}
+
+// m365UserDateFields maps m365_copilot_user date properties to their
+// user detail fields.
+var m365UserDateFields = map[string]string{
+	"last_activity_date":            "lastActivityDate",
+	"teams_copilot_last_activity":   "microsoftTeamsCopilotLastActivityDate",
+	"word_copilot_last_activity":    "wordCopilotLastActivityDate",
+	"excel_copilot_last_activity":   "excelCopilotLastActivityDate",
+	"ppt_copilot_last_activity":     "powerPointCopilotLastActivityDate",
+	"outlook_copilot_last_activity": "outlookCopilotLastActivityDate",
+	"onenote_copilot_last_activity": "oneNoteCopilotLastActivityDate",
+	"loop_copilot_last_activity":    "loopCopilotLastActivityDate",
+	"chat_last_activity":            "copilotChatLastActivityDate",
+}
+
+// m365UserProps maps one user detail row to m365_copilot_user properties.
+// Dates are sent as RFC3339; empty or unparseable ones are left out.
+func m365UserProps(period, recordDate string, u map[string]any) map[string]any {
+	upn := str(u["userPrincipalName"])
+	hash := upn
+	if hash == "" {
+		if dn := str(u["displayName"]); dn != "" {
+			hash = dn
+		} else {
+			hash = stableMapFingerprint(u)
+		}
+	}
+	props := map[string]any{
+		"period":              period,
+		"report_date":         recordDate,
+		"user_principal_name": upn,
+		"user_hash":           sha256Hex(hash),
+	}
+	setDate(props, "report_refresh_date", u["reportRefreshDate"])
+	for key, field := range m365UserDateFields {
+		setDate(props, key, u[field])
+	}
+	return props
+}
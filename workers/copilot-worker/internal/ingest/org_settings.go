@@ -0,0 +1,166 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// policyFields are the github_copilot_org_settings properties compared
+// between runs to flag policy changes.
+var policyFields = []string{
+	"plan_type",
+	"seat_management_setting",
+	"public_code_suggestions",
+	"ide_chat",
+	"platform_chat",
+	"cli",
+}
+
+// orgPolicyState is an org's policies as of its last settings snapshot.
+type orgPolicyState struct {
+	RecordDate string            `json:"record_date"`
+	Policies   map[string]string `json:"policies"`
+}
+
+// GitHubOrgSettings snapshots every org's Copilot plan, policies and seat
+// breakdown (github_copilot_org_settings, org@date) and flags policies that
+// changed since the org's previous snapshot.
+func GitHubOrgSettings(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, recordDate string) {
+	prev, err := loadOrgPolicies(ctx, cfg, pcli)
+	if err != nil {
+		log.Printf("warn: gh org settings: previous policies: %v; change detection off this run", err)
+	}
+	var (
+		mu   sync.Mutex
+		next = map[string]orgPolicyState{}
+	)
+	forEachOrg(cfg, func(org config.GitHubOrg) {
+		s, err := githubapi.FetchOrgCopilotSettings(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name)
+		if err != nil {
+			log.Printf("warn: gh org settings %s: %v", org.Name, err)
+			return
+		}
+		props := orgSettingsProps(s, org.Name, recordDate)
+		cur := orgPolicyState{RecordDate: recordDate, Policies: map[string]string{}}
+		for _, f := range policyFields {
+			cur.Policies[f] = str(props[f])
+		}
+		if p, ok := prev[org.Name]; ok {
+			changes := diffPolicies(p.Policies, cur.Policies)
+			props["policy_changed"] = len(changes) > 0
+			props["changed_policies"] = changes
+			props["previous_record_date"] = p.RecordDate
+			if len(changes) > 0 {
+				log.Printf("warn: gh org settings %s: policy changed since %s: %s", org.Name, p.RecordDate, strings.Join(changes, "; "))
+			}
+		}
+		if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-org-settings", "github_copilot_org_settings",
+			org.Name+"@"+recordDate, props, nil); err != nil {
+			log.Printf("warn: gh org settings upsert %s: %v", org.Name, err)
+		}
+		mu.Lock()
+		next[org.Name] = cur
+		mu.Unlock()
+	})
+	if cfg.OrgSettingsStateFile == "" {
+		return
+	}
+	for org, p := range prev {
+		if _, ok := next[org]; !ok {
+			next[org] = p
+		}
+	}
+	if err := writeStateFile(cfg.OrgSettingsStateFile, next); err != nil {
+		log.Printf("warn: gh org settings: save policies: %v", err)
+	}
+}
+
+func orgSettingsProps(s githubapi.OrgCopilotSettings, org, recordDate string) map[string]any {
+	b := s.SeatBreakdown
+	return map[string]any{
+		"record_date":                recordDate,
+		"git_hub_org":                org,
+		"plan_type":                  s.PlanType,
+		"seat_management_setting":    s.SeatManagementSetting,
+		"public_code_suggestions":    s.PublicCodeSuggestions,
+		"ide_chat":                   s.IDEChat,
+		"platform_chat":              s.PlatformChat,
+		"cli":                        s.CLI,
+		"seats_total":                b.Total,
+		"seats_added_this_cycle":     b.AddedThisCycle,
+		"seats_pending_invitation":   b.PendingInvitation,
+		"seats_pending_cancellation": b.PendingCancellation,
+		"seats_active_this_cycle":    b.ActiveThisCycle,
+		"seats_inactive_this_cycle":  b.InactiveThisCycle,
+	}
+}
+
+// diffPolicies lists changed policies as "field: old -> new".
+func diffPolicies(prev, cur map[string]string) []string {
+	changes := []string{}
+	for _, f := range policyFields {
+		if prev[f] != cur[f] {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", f, orDash(prev[f]), orDash(cur[f])))
+		}
+	}
+	return changes
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// loadOrgPolicies reads each org's last policies from ORG_SETTINGS_STATE_FILE,
+// else from the latest github_copilot_org_settings entities when the Port
+// API is available. It returns nil (no detection) when neither is.
+func loadOrgPolicies(ctx context.Context, cfg config.Config, pcli *portapi.Client) (map[string]orgPolicyState, error) {
+	if cfg.OrgSettingsStateFile != "" {
+		out := map[string]orgPolicyState{}
+		b, err := os.ReadFile(cfg.OrgSettingsStateFile)
+		if errors.Is(err, os.ErrNotExist) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &out); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", cfg.OrgSettingsStateFile, err)
+		}
+		return out, nil
+	}
+	if pcli == nil {
+		log.Printf("gh org settings: set ORG_SETTINGS_STATE_FILE or Port API credentials to flag policy changes")
+		return nil, nil
+	}
+	ents, err := pcli.ListEntities(ctx, "github_copilot_org_settings")
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]orgPolicyState{}
+	for _, e := range ents {
+		org, rd := str(e.Properties["git_hub_org"]), str(e.Properties["record_date"])
+		if org == "" || rd <= out[org].RecordDate {
+			continue
+		}
+		st := orgPolicyState{RecordDate: rd, Policies: map[string]string{}}
+		for _, f := range policyFields {
+			st.Policies[f] = str(e.Properties[f])
+		}
+		out[org] = st
+	}
+	return out, nil
+}
@@ -0,0 +1,270 @@
+package ingest
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// premiumScope is an org or an enterprise whose premium usage is ingested.
+type premiumScope struct {
+	name     string
+	idPrefix string
+	props    map[string]any
+	seats    []githubapi.Seat
+	// org is the scope's org, "" for an enterprise; seatOrg names the org a
+	// seat belongs to (for user and seat relations).
+	org     string
+	seatOrg func(githubapi.Seat) string
+	token   string
+	fetch   func(day time.Time, user string) ([]githubapi.PremiumUsageItem, error)
+}
+
+// GitHubPremiumUsage ingests Copilot premium request usage per user, model
+// and day (github_copilot_premium_usage) for the last
+// GITHUB_PREMIUM_USAGE_DAYS days, per org or for the enterprise. Each day
+// is one report call grouped by the user on each line; only when the report
+// does not name users are seat holders queried one by one, which costs one
+// call per seat and day with usage.
+func GitHubPremiumUsage(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client) {
+	if cfg.GitHubEnterprise != "" {
+		ent := cfg.GitHubEnterprise
+		seats, err := githubapi.FetchEnterpriseSeats(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, cfg.GitHubEnterpriseToken, ent)
+		if err != nil {
+			log.Printf("warn: gh premium usage %s: seats: %v", ent, err)
+			return
+		}
+		premiumUsage(ctx, cfg, hc, pcli, premiumScope{
+			name:     ent,
+			idPrefix: "enterprise/" + ent + "/",
+			props:    map[string]any{"git_hub_enterprise": ent},
+			seats:    seats,
+			seatOrg:  githubapi.Seat.Org,
+			token:    cfg.GitHubToken,
+			fetch: func(day time.Time, user string) ([]githubapi.PremiumUsageItem, error) {
+				return githubapi.FetchEnterprisePremiumUsage(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, cfg.GitHubEnterpriseToken, ent, day, user)
+			},
+		})
+		return
+	}
+	forEachOrg(cfg, func(org config.GitHubOrg) {
+		seats, err := githubapi.FetchSeats(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name)
+		if err != nil {
+			log.Printf("warn: gh premium usage %s: seats: %v", org.Name, err)
+			return
+		}
+		premiumUsage(ctx, cfg, hc, pcli, premiumScope{
+			name:     org.Name,
+			idPrefix: org.Name + "/",
+			props:    map[string]any{"git_hub_org": org.Name},
+			seats:    seats,
+			org:      org.Name,
+			seatOrg:  func(githubapi.Seat) string { return org.Name },
+			token:    org.Token,
+			fetch: func(day time.Time, user string) ([]githubapi.PremiumUsageItem, error) {
+				return githubapi.FetchOrgPremiumUsage(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name, day, user)
+			},
+		})
+	})
+}
+
+func premiumUsage(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, sc premiumScope) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	seatByLogin := map[string]githubapi.Seat{}
+	emails := map[string]map[string]string{}
+	if sc.org != "" {
+		emails[sc.org] = orgEmails(ctx, cfg, hc, sc.org, sc.token)
+	}
+	for _, s := range sc.seats {
+		if login := s.Login(); login != "" {
+			seatByLogin[strings.ToLower(login)] = s
+		}
+		if org := sc.seatOrg(s); org != "" {
+			if _, ok := emails[org]; !ok {
+				emails[org] = orgEmails(ctx, cfg, hc, org, sc.token)
+			}
+		}
+	}
+	emitted := 0
+	for i := cfg.GitHubPremiumUsageDays - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		date := day.Format("2006-01-02")
+		total, err := sc.fetch(day, "")
+		if err != nil {
+			log.Printf("warn: gh premium usage %s@%s: %v", sc.name, date, err)
+			continue
+		}
+		if !hasPremiumUsage(total) {
+			continue
+		}
+		byUser, ok := premiumByUser(total)
+		if !ok {
+			if len(sc.seats) > cfg.GitHubPremiumPerSeatMax {
+				log.Printf("warn: gh premium usage %s@%s: report has no per-user lines and %d seat(s) is over GITHUB_PREMIUM_PER_SEAT_MAX=%d; skipping day",
+					sc.name, date, len(sc.seats), cfg.GitHubPremiumPerSeatMax)
+				continue
+			}
+			byUser = premiumBySeat(ctx, cfg, sc, day)
+		}
+		for login, items := range byUser {
+			org := sc.org
+			s, hasSeat := seatByLogin[strings.ToLower(login)]
+			if hasSeat {
+				org = sc.seatOrg(s)
+			}
+			rels := map[string]any{}
+			if hasSeat {
+				if email := seatEmail(s, emails[org]); email != "" {
+					rels["port_user"] = email
+				}
+			} else if email := emails[org][strings.ToLower(login)]; email != "" {
+				rels["port_user"] = email
+			}
+			if cfg.EnableGitHubSeatDetails && hasSeat && org != "" {
+				rels["seat"] = org + "/" + login
+			}
+			for model, it := range premiumByModel(items) {
+				props := premiumProps(it, login, date)
+				for k, v := range sc.props {
+					props[k] = v
+				}
+				id := sc.idPrefix + login + "/" + idSlug(model) + "@" + date
+				if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookUsageURL, "gh-copilot-premium-usage", "github_copilot_premium_usage",
+					id, props, rels); err != nil {
+					log.Printf("warn: gh premium usage %s: %v", id, err)
+					continue
+				}
+				emitted++
+			}
+		}
+	}
+	log.Printf("gh premium usage: %d entities over %d day(s) for %s", emitted, cfg.GitHubPremiumUsageDays, sc.name)
+}
+
+// premiumByUser groups report lines by the user they name. ok is false when
+// a line with usage names no user, i.e. the report was not broken down.
+func premiumByUser(items []githubapi.PremiumUsageItem) (map[string][]githubapi.PremiumUsageItem, bool) {
+	out := map[string][]githubapi.PremiumUsageItem{}
+	for _, it := range items {
+		if it.GrossQuantity <= 0 {
+			continue
+		}
+		if it.User == "" {
+			return nil, false
+		}
+		out[it.User] = append(out[it.User], it)
+	}
+	return out, true
+}
+
+// premiumBySeat queries the report once per seat holder for day, with at
+// most cfg.GitHubConcurrency calls in flight. Users without a seat are not
+// covered.
+func premiumBySeat(ctx context.Context, cfg config.Config, sc premiumScope, day time.Time) map[string][]githubapi.PremiumUsageItem {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		out = map[string][]githubapi.PremiumUsageItem{}
+	)
+	log.Printf("gh premium usage %s@%s: report has no per-user lines; querying %d seat holder(s)", sc.name, day.Format("2006-01-02"), len(sc.seats))
+	sem := make(chan struct{}, cfg.GitHubConcurrency)
+	for _, s := range sc.seats {
+		login := s.Login()
+		if login == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(login string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			items, err := sc.fetch(day, login)
+			if err != nil {
+				log.Printf("warn: gh premium usage %s/%s@%s: %v", sc.name, login, day.Format("2006-01-02"), err)
+				return
+			}
+			if !hasPremiumUsage(items) {
+				return
+			}
+			mu.Lock()
+			out[login] = items
+			mu.Unlock()
+		}(login)
+	}
+	wg.Wait()
+	return out
+}
+
+func hasPremiumUsage(items []githubapi.PremiumUsageItem) bool {
+	for _, it := range items {
+		if it.GrossQuantity > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// premiumByModel sums report lines per model, dropping models without use.
+func premiumByModel(items []githubapi.PremiumUsageItem) map[string]githubapi.PremiumUsageItem {
+	out := map[string]githubapi.PremiumUsageItem{}
+	for _, it := range items {
+		if it.GrossQuantity <= 0 {
+			continue
+		}
+		model := it.Model
+		if model == "" {
+			model = "unknown"
+		}
+		acc, ok := out[model]
+		if !ok {
+			acc = githubapi.PremiumUsageItem{Product: it.Product, SKU: it.SKU, Model: model, UnitType: it.UnitType, PricePerUnit: it.PricePerUnit}
+		}
+		acc.GrossQuantity += it.GrossQuantity
+		acc.GrossAmount += it.GrossAmount
+		acc.DiscountQuantity += it.DiscountQuantity
+		acc.DiscountAmount += it.DiscountAmount
+		acc.NetQuantity += it.NetQuantity
+		acc.NetAmount += it.NetAmount
+		out[model] = acc
+	}
+	return out
+}
+
+func premiumProps(it githubapi.PremiumUsageItem, login, date string) map[string]any {
+	return map[string]any{
+		"record_date":       date + "T00:00:00Z",
+		"login":             login,
+		"model":             it.Model,
+		"sku":               it.SKU,
+		"unit_type":         it.UnitType,
+		"price_per_unit":    it.PricePerUnit,
+		"gross_quantity":    it.GrossQuantity,
+		"gross_amount":      roundCents(it.GrossAmount),
+		"discount_quantity": it.DiscountQuantity,
+		"discount_amount":   roundCents(it.DiscountAmount),
+		"net_quantity":      it.NetQuantity,
+		"net_amount":        roundCents(it.NetAmount),
+	}
+}
+
+// idSlug makes a free-form name (e.g. a model like "GPT-4.1 mini") safe for
+// Port identifiers.
+func idSlug(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+func TestPremiumUsagePerSeatFallbackIsCapped(t *testing.T) {
+	seats := []githubapi.Seat{
+		{Assignee: &githubapi.Account{Login: "ann"}},
+		{Assignee: &githubapi.Account{Login: "bob"}},
+	}
+	tests := []struct {
+		name      string
+		max       int
+		wantCalls []string
+		wantIDs   []string
+	}{
+		{name: "off by default", wantCalls: []string{""}},
+		{name: "over the cap", max: 1, wantCalls: []string{""}},
+		{
+			name:      "within the cap",
+			max:       2,
+			wantCalls: []string{"", "ann", "bob"},
+			wantIDs:   []string{"acme/ann/gpt-5@", "acme/bob/gpt-5@"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu  sync.Mutex
+				ids []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var p struct {
+					Identifier string `json:"identifier"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&p)
+				mu.Lock()
+				ids = append(ids, p.Identifier)
+				mu.Unlock()
+			}))
+			defer srv.Close()
+			cfg := config.Config{
+				UseWebhook:              true,
+				WebhookUsageURL:         srv.URL,
+				GitHubConcurrency:       2,
+				GitHubPremiumUsageDays:  1,
+				GitHubPremiumPerSeatMax: tt.max,
+			}
+			var calls []string
+			premiumUsage(context.Background(), cfg, srv.Client(), nil, premiumScope{
+				name:     "acme",
+				idPrefix: "acme/",
+				seats:    seats,
+				org:      "acme",
+				seatOrg:  func(githubapi.Seat) string { return "acme" },
+				fetch: func(day time.Time, user string) ([]githubapi.PremiumUsageItem, error) {
+					mu.Lock()
+					calls = append(calls, user)
+					mu.Unlock()
+					return []githubapi.PremiumUsageItem{{Model: "GPT-5", GrossQuantity: 3}}, nil
+				},
+			})
+			sort.Strings(calls)
+			if len(calls) != len(tt.wantCalls) {
+				t.Fatalf("report calls = %q, want %q", calls, tt.wantCalls)
+			}
+			for i := range calls {
+				if calls[i] != tt.wantCalls[i] {
+					t.Errorf("report calls = %q, want %q", calls, tt.wantCalls)
+				}
+			}
+			date := time.Now().UTC().Format("2006-01-02")
+			sort.Strings(ids)
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("upserts = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i]+date {
+					t.Errorf("upsert %d = %s, want %s%s", i, ids[i], tt.wantIDs[i], date)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// reclaimBatchSize bounds the usernames sent per selected_users DELETE.
+const reclaimBatchSize = 100
+
+// ReclaimReport is the dry-run (or applied) plan for one org.
+type ReclaimReport struct {
+	Org          string             `json:"org"`
+	GeneratedAt  string             `json:"generated_at"`
+	InactiveDays int                `json:"inactive_days"`
+	DryRun       bool               `json:"dry_run"`
+	Candidates   []ReclaimCandidate `json:"candidates"`
+	Protected    []ReclaimCandidate `json:"protected"`
+	Cancelled    int                `json:"seats_cancelled"`
+	NotCancelled []string           `json:"not_cancelled,omitempty"`
+}
+
+// ReclaimCandidate is one dormant seat and why it is (not) removed.
+type ReclaimCandidate struct {
+	Login          string `json:"login"`
+	AssignedAt     string `json:"assigned_at,omitempty"`
+	LastActivityAt string `json:"last_activity_at,omitempty"`
+	AssigningTeam  string `json:"assigning_team,omitempty"`
+	InactiveDays   int    `json:"inactive_days"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// Reclaim finds seats idle for cfg.ReclaimInactiveDays (last activity, or
+// assignment date for never-used seats) in every configured org. It always
+// writes a report; only with RECLAIM_CONFIRM=true does it cancel the seats
+// and record each removal as a github_copilot_seat_reclamation entity.
+func Reclaim(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, recordDate string) {
+	var reports []ReclaimReport
+	for _, org := range cfg.GitHubOrgs {
+		rep, ok := reclaimOrg(ctx, cfg, hc, pcli, org, recordDate)
+		if ok {
+			reports = append(reports, rep)
+		}
+	}
+	b, _ := json.MarshalIndent(reports, "", "  ")
+	if cfg.ReclaimReportFile == "" {
+		log.Printf("reclaim report:\n%s", b)
+		return
+	}
+	if err := os.WriteFile(cfg.ReclaimReportFile, b, 0o600); err != nil {
+		log.Printf("warn: reclaim report: %v", err)
+		return
+	}
+	log.Printf("reclaim report written to %s", cfg.ReclaimReportFile)
+}
+
+func reclaimOrg(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, org config.GitHubOrg, recordDate string) (ReclaimReport, bool) {
+	rep := ReclaimReport{
+		Org:          org.Name,
+		GeneratedAt:  recordDate,
+		InactiveDays: cfg.ReclaimInactiveDays,
+		DryRun:       !cfg.ReclaimConfirm,
+	}
+	seats, err := githubapi.FetchSeats(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name)
+	if err != nil {
+		log.Printf("warn: reclaim %s: %v", org.Name, err)
+		return rep, false
+	}
+	allowed := map[string]string{}
+	for _, u := range cfg.ReclaimAllowUsers {
+		allowed[strings.ToLower(u)] = "allow-listed user"
+	}
+	for _, team := range cfg.ReclaimAllowTeams {
+		members, err := githubapi.ListTeamMembers(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name, team)
+		if err != nil {
+			// Without the member list we cannot honour the allow-list safely.
+			log.Printf("warn: reclaim %s: allow-listed team %s: %v; skipping org", org.Name, team, err)
+			return rep, false
+		}
+		for _, m := range members {
+			allowed[strings.ToLower(m)] = "member of allow-listed team " + team
+		}
+	}
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -cfg.ReclaimInactiveDays)
+	var logins []string
+	for _, s := range seats {
+		login := s.Login()
+		ref := s.LastActivityAt
+		if ref == nil {
+			ref = s.AssignedAt
+		}
+		if login == "" || ref == nil || !ref.Before(cutoff) || s.PendingCancellationDate != "" {
+			continue
+		}
+		c := ReclaimCandidate{Login: login, InactiveDays: int(now.Sub(*ref).Hours() / 24)}
+		if s.AssignedAt != nil {
+			c.AssignedAt = s.AssignedAt.UTC().Format(time.RFC3339)
+		}
+		if s.LastActivityAt != nil {
+			c.LastActivityAt = s.LastActivityAt.UTC().Format(time.RFC3339)
+		}
+		if s.AssigningTeam != nil {
+			c.AssigningTeam = s.AssigningTeam.Slug
+		}
+		switch {
+		case allowed[strings.ToLower(login)] != "":
+			c.Reason = allowed[strings.ToLower(login)]
+		case containsFold(cfg.ReclaimAllowTeams, c.AssigningTeam):
+			c.Reason = "assigned via allow-listed team " + c.AssigningTeam
+		case c.AssigningTeam != "":
+			// selected_users cannot cancel team-granted seats.
+			c.Reason = "assigned via team " + c.AssigningTeam + "; remove from team instead"
+		}
+		if c.Reason != "" {
+			rep.Protected = append(rep.Protected, c)
+			continue
+		}
+		rep.Candidates = append(rep.Candidates, c)
+		logins = append(logins, login)
+	}
+	log.Printf("reclaim %s: %d dormant seat(s) over %d day(s), %d protected, dry_run=%t",
+		org.Name, len(rep.Candidates), cfg.ReclaimInactiveDays, len(rep.Protected), rep.DryRun)
+	if rep.DryRun || len(logins) == 0 {
+		return rep, true
+	}
+
+	byLogin := map[string]ReclaimCandidate{}
+	for _, c := range rep.Candidates {
+		byLogin[c.Login] = c
+	}
+	var unconfirmed []string
+	for start := 0; start < len(logins); start += reclaimBatchSize {
+		batch := logins[start:min(start+reclaimBatchSize, len(logins))]
+		n, err := githubapi.RemoveSeats(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name, batch)
+		if err != nil {
+			log.Printf("warn: reclaim %s: remove %d seat(s): %v", org.Name, len(batch), err)
+			continue
+		}
+		rep.Cancelled += n
+		if n != len(batch) {
+			// GitHub skips seats it cannot cancel without saying which.
+			log.Printf("warn: reclaim %s: %d of %d seat(s) cancelled; checking which", org.Name, n, len(batch))
+			unconfirmed = append(unconfirmed, batch...)
+			continue
+		}
+		for _, login := range batch {
+			recordReclamation(ctx, cfg, hc, pcli, org.Name, byLogin[login], recordDate)
+		}
+	}
+	if len(unconfirmed) > 0 {
+		rep.NotCancelled = confirmReclaimed(ctx, cfg, hc, pcli, org, unconfirmed, byLogin, recordDate)
+	}
+	log.Printf("reclaim %s: %d seat(s) cancelled", org.Name, rep.Cancelled)
+	return rep, true
+}
+
+// confirmReclaimed re-fetches the org's seats after a partial removal and
+// audits only the logins whose seat is now pending cancellation. It returns
+// the logins that were not cancelled (all of them if the seats cannot be
+// read, since nothing can be confirmed).
+func confirmReclaimed(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, org config.GitHubOrg, logins []string, byLogin map[string]ReclaimCandidate, recordDate string) []string {
+	seats, err := githubapi.FetchSeats(ctx, hc, cfg.GitHubAPIBase, cfg.GitHubAPIVer, org.Token, org.Name)
+	if err != nil {
+		log.Printf("warn: reclaim %s: re-read seats: %v; not auditing %d unconfirmed removal(s)", org.Name, err, len(logins))
+		return logins
+	}
+	pending := map[string]bool{}
+	for _, s := range seats {
+		if s.PendingCancellationDate != "" {
+			pending[strings.ToLower(s.Login())] = true
+		}
+	}
+	var missed []string
+	for _, login := range logins {
+		if !pending[strings.ToLower(login)] {
+			missed = append(missed, login)
+			continue
+		}
+		recordReclamation(ctx, cfg, hc, pcli, org.Name, byLogin[login], recordDate)
+	}
+	if len(missed) > 0 {
+		log.Printf("warn: reclaim %s: %d seat(s) not cancelled: %s", org.Name, len(missed), strings.Join(missed, ", "))
+	}
+	return missed
+}
+
+func recordReclamation(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, org string, c ReclaimCandidate, recordDate string) {
+	props := map[string]any{
+		"login":          c.Login,
+		"git_hub_org":    org,
+		"reclaimed_at":   recordDate,
+		"inactive_days":  c.InactiveDays,
+		"threshold_days": cfg.ReclaimInactiveDays,
+	}
+	if c.AssignedAt != "" {
+		props["assigned_at"] = c.AssignedAt
+	}
+	if c.LastActivityAt != "" {
+		props["last_activity_at"] = c.LastActivityAt
+	}
+	rels := map[string]any{}
+	if cfg.EnableGitHubSeatDetails {
+		rels["seat"] = org + "/" + c.Login
+	}
+	if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-seat-reclamation", "github_copilot_seat_reclamation",
+		org+"/"+c.Login+"@"+recordDate, props, rels); err != nil {
+		log.Printf("warn: reclaim audit %s/%s: %v", org, c.Login, err)
+	}
+}
@@ -0,0 +1,258 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+)
+
+// fakeReclaimAPI serves the GitHub seat, team member and seat removal
+// endpoints for one org, plus a Port webhook that records audit entities.
+type fakeReclaimAPI struct {
+	mu      sync.Mutex
+	seats   []map[string]any
+	members map[string][]string
+	// cancellable says whether a DELETE cancels login; nil cancels all.
+	cancellable func(login string) bool
+	// rereadFails makes every seats read after a DELETE return 403.
+	rereadFails bool
+
+	deleted []string
+	audited []string
+}
+
+func (f *fakeReclaimAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.URL.Path == "/hook":
+		var p struct {
+			Kind       string `json:"kind"`
+			Identifier string `json:"identifier"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		if p.Kind == "gh-copilot-seat-reclamation" {
+			f.audited = append(f.audited, p.Identifier)
+		}
+	case r.URL.Path == "/orgs/acme/copilot/billing/seats":
+		if f.rereadFails && len(f.deleted) > 0 {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"seats": f.seats})
+	case strings.HasPrefix(r.URL.Path, "/orgs/acme/teams/"):
+		slug := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/orgs/acme/teams/"), "/members")
+		var out []map[string]string
+		for _, m := range f.members[slug] {
+			out = append(out, map[string]string{"login": m})
+		}
+		if out == nil {
+			out = []map[string]string{}
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	case r.Method == "DELETE" && r.URL.Path == "/orgs/acme/copilot/billing/selected_users":
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Logins []string `json:"selected_usernames"`
+		}
+		_ = json.Unmarshal(body, &req)
+		n := 0
+		for _, login := range req.Logins {
+			f.deleted = append(f.deleted, login)
+			if f.cancellable != nil && !f.cancellable(login) {
+				continue
+			}
+			for _, s := range f.seats {
+				if s["assignee"].(map[string]any)["login"] == login {
+					s["pending_cancellation_date"] = "2030-01-31"
+					n++
+				}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]int{"seats_cancelled": n})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func reclaimSeat(login string, lastActive *time.Time, assigned time.Time, team string) map[string]any {
+	s := map[string]any{
+		"assignee":   map[string]any{"login": login, "type": "User"},
+		"created_at": assigned.UTC().Format(time.RFC3339),
+	}
+	if lastActive != nil {
+		s["last_activity_at"] = lastActive.UTC().Format(time.RFC3339)
+	}
+	if team != "" {
+		s["assigning_team"] = map[string]any{"slug": team, "name": team}
+	}
+	return s
+}
+
+func logins(cs []ReclaimCandidate) []string {
+	out := make([]string, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, c.Login)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestReclaimOrg(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(d int) *time.Time { t := now.AddDate(0, 0, -d); return &t }
+	seats := func() []map[string]any {
+		pending := reclaimSeat("leaving", daysAgo(200), *daysAgo(300), "")
+		pending["pending_cancellation_date"] = "2030-01-31"
+		return []map[string]any{
+			reclaimSeat("active", daysAgo(3), *daysAgo(300), ""),
+			reclaimSeat("dormant", daysAgo(120), *daysAgo(300), ""),
+			reclaimSeat("never-used", nil, *daysAgo(200), ""),
+			reclaimSeat("new-hire", nil, *daysAgo(10), ""),
+			reclaimSeat("vip", daysAgo(150), *daysAgo(300), ""),
+			reclaimSeat("sre-member", daysAgo(150), *daysAgo(300), ""),
+			reclaimSeat("team-granted", daysAgo(150), *daysAgo(300), "design"),
+			reclaimSeat("protected-team-seat", daysAgo(150), *daysAgo(300), "sre"),
+			pending,
+		}
+	}
+	tests := []struct {
+		name          string
+		confirm       bool
+		cancellable   func(string) bool
+		rereadFails   bool
+		wantCands     []string
+		wantProtected []string
+		wantDeleted   []string
+		wantAudited   []string
+		wantCancelled int
+		wantNot       []string
+	}{
+		{
+			name:          "dry run by default",
+			wantCands:     []string{"dormant", "never-used"},
+			wantProtected: []string{"protected-team-seat", "sre-member", "team-granted", "vip"},
+		},
+		{
+			name:          "confirmed removal audits every cancelled seat",
+			confirm:       true,
+			wantCands:     []string{"dormant", "never-used"},
+			wantProtected: []string{"protected-team-seat", "sre-member", "team-granted", "vip"},
+			wantDeleted:   []string{"dormant", "never-used"},
+			wantAudited:   []string{"acme/dormant", "acme/never-used"},
+			wantCancelled: 2,
+		},
+		{
+			name:          "partial removal audits only seats now pending",
+			confirm:       true,
+			cancellable:   func(login string) bool { return login == "dormant" },
+			wantCands:     []string{"dormant", "never-used"},
+			wantProtected: []string{"protected-team-seat", "sre-member", "team-granted", "vip"},
+			wantDeleted:   []string{"dormant", "never-used"},
+			wantAudited:   []string{"acme/dormant"},
+			wantCancelled: 1,
+			wantNot:       []string{"never-used"},
+		},
+		{
+			name:          "partial removal with unreadable seats audits nothing",
+			confirm:       true,
+			cancellable:   func(login string) bool { return login == "dormant" },
+			rereadFails:   true,
+			wantCands:     []string{"dormant", "never-used"},
+			wantProtected: []string{"protected-team-seat", "sre-member", "team-granted", "vip"},
+			wantDeleted:   []string{"dormant", "never-used"},
+			wantCancelled: 1,
+			wantNot:       []string{"dormant", "never-used"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeReclaimAPI{
+				seats:       seats(),
+				members:     map[string][]string{"sre": {"sre-member"}},
+				cancellable: tt.cancellable,
+				rereadFails: tt.rereadFails,
+			}
+			srv := httptest.NewServer(api)
+			defer srv.Close()
+			cfg := config.Config{
+				GitHubAPIBase:       srv.URL,
+				GitHubAPIVer:        "2022-11-28",
+				ReclaimInactiveDays: 90,
+				ReclaimAllowUsers:   []string{"VIP"},
+				ReclaimAllowTeams:   []string{"sre"},
+				ReclaimConfirm:      tt.confirm,
+				UseWebhook:          true,
+				WebhookSeatsURL:     srv.URL + "/hook",
+			}
+			rep, ok := reclaimOrg(context.Background(), cfg, srv.Client(), nil, config.GitHubOrg{Name: "acme", Token: "tok"}, "2030-01-01T00:00:00Z")
+			if !ok {
+				t.Fatal("reclaimOrg reported failure")
+			}
+			if rep.DryRun == tt.confirm {
+				t.Errorf("DryRun = %v with confirm %v", rep.DryRun, tt.confirm)
+			}
+			if got := logins(rep.Candidates); !reflect.DeepEqual(got, tt.wantCands) {
+				t.Errorf("candidates = %v, want %v", got, tt.wantCands)
+			}
+			if got := logins(rep.Protected); !reflect.DeepEqual(got, tt.wantProtected) {
+				t.Errorf("protected = %v, want %v", got, tt.wantProtected)
+			}
+			sort.Strings(api.deleted)
+			if !reflect.DeepEqual(api.deleted, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", api.deleted, tt.wantDeleted)
+			}
+			var audited []string
+			for _, id := range api.audited {
+				audited = append(audited, strings.SplitN(id, "@", 2)[0])
+			}
+			sort.Strings(audited)
+			if !reflect.DeepEqual(audited, tt.wantAudited) {
+				t.Errorf("audited = %v, want %v", audited, tt.wantAudited)
+			}
+			if rep.Cancelled != tt.wantCancelled {
+				t.Errorf("cancelled = %d, want %d", rep.Cancelled, tt.wantCancelled)
+			}
+			sort.Strings(rep.NotCancelled)
+			if !reflect.DeepEqual(rep.NotCancelled, tt.wantNot) {
+				t.Errorf("not cancelled = %v, want %v", rep.NotCancelled, tt.wantNot)
+			}
+		})
+	}
+}
+
+func TestReclaimOrgSkipsOrgWhenAllowTeamUnreadable(t *testing.T) {
+	api := &fakeReclaimAPI{seats: []map[string]any{reclaimSeat("dormant", nil, time.Now().AddDate(-1, 0, 0), "")}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/teams/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		api.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	cfg := config.Config{
+		GitHubAPIBase:       srv.URL,
+		ReclaimInactiveDays: 30,
+		ReclaimAllowTeams:   []string{"sre"},
+		ReclaimConfirm:      true,
+		UseWebhook:          true,
+		WebhookSeatsURL:     srv.URL + "/hook",
+	}
+	if _, ok := reclaimOrg(context.Background(), cfg, srv.Client(), nil, config.GitHubOrg{Name: "acme"}, "2030-01-01T00:00:00Z"); ok {
+		t.Error("reclaimOrg should skip the org when an allow-listed team cannot be read")
+	}
+	if len(api.deleted) != 0 {
+		t.Errorf("deleted = %v, want none", api.deleted)
+	}
+}
@@ -0,0 +1,85 @@
+package ingest
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// seatCosts estimates this calendar month's spend (UTC) for a set of seats.
+// With SEAT_BILLING_MODE=prorated a seat is billed from its assignment (or
+// the month start) until its pending cancellation date (or the month end);
+// with monthly every seat is billed the full month. Idle seats have had no
+// activity (or, if never used, no assignment) for SEAT_IDLE_DAYS; their
+// cost is their projected cost for the month.
+func seatCosts(cfg config.Config, seats []githubapi.Seat, now time.Time) map[string]any {
+	now = now.UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	month := monthEnd.Sub(monthStart)
+	idleCut := now.AddDate(0, 0, -cfg.SeatIdleDays)
+
+	var mtd, projected, idle float64
+	var idleSeats int
+	for _, s := range seats {
+		price := seatPrice(cfg, s.PlanType)
+		start, end := monthStart, monthEnd
+		if cfg.SeatBillingMode == "prorated" {
+			if s.AssignedAt != nil && s.AssignedAt.After(start) {
+				start = s.AssignedAt.UTC()
+			}
+			if t, err := time.Parse("2006-01-02", s.PendingCancellationDate); err == nil && t.Before(end) {
+				end = t
+			}
+		}
+		if !end.After(start) {
+			continue
+		}
+		share := func(to time.Time) float64 {
+			if to.After(end) {
+				to = end
+			}
+			if !to.After(start) {
+				return 0
+			}
+			return price * float64(to.Sub(start)) / float64(month)
+		}
+		mtd += share(now)
+		seatMonth := share(end)
+		projected += seatMonth
+
+		ref := s.LastActivityAt
+		if ref == nil {
+			ref = s.AssignedAt
+		}
+		if ref != nil && ref.Before(idleCut) {
+			idleSeats++
+			idle += seatMonth
+		}
+	}
+	return map[string]any{
+		"cost_mtd":             roundCents(mtd),
+		"cost_projected_month": roundCents(projected),
+		"cost_idle":            roundCents(idle),
+		"seats_idle":           idleSeats,
+		"idle_threshold_days":  cfg.SeatIdleDays,
+		"cost_currency":        cfg.SeatPriceCurrency,
+		"billing_mode":         cfg.SeatBillingMode,
+	}
+}
+
+// seatPrice returns the monthly price for a seat's plan_type; unknown plans
+// are priced as Business, the cheaper plan.
+func seatPrice(cfg config.Config, plan string) float64 {
+	if strings.EqualFold(plan, "enterprise") {
+		return cfg.SeatPriceEnterprise
+	}
+	return cfg.SeatPriceBusiness
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,314 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// Seat event types emitted as github_copilot_seat_event.event_type.
+const (
+	seatAdded               = "added"
+	seatRemoved             = "removed"
+	seatPendingCancellation = "pending_cancellation"
+	seatReactivated         = "reactivated"
+)
+
+// seatState is what we remember about a seat between runs.
+type seatState struct {
+	AssignedAt          string `json:"assigned_at,omitempty"`
+	PendingCancellation string `json:"pending_cancellation_date,omitempty"`
+}
+
+// seatSnapshot is the persisted seat list: org -> login -> state.
+type seatSnapshot struct {
+	RecordDate string                          `json:"record_date"`
+	Orgs       map[string]map[string]seatState `json:"orgs"`
+}
+
+// seatTracker holds the previous run's seat list, collects the seats seen
+// in this run and, on flush, emits seat events for the differences.
+// complete marks a run where every org was fetched in one call (enterprise
+// mode), so orgs missing now lost all their seats rather than failed to load.
+// failed holds, per org, the logins whose github_copilot_seat upsert failed.
+type seatTracker struct {
+	prev     seatSnapshot
+	mu       sync.Mutex
+	current  map[string]map[string]seatState
+	failed   map[string]map[string]bool
+	complete bool
+}
+
+// newSeatTracker loads the previous seat list; it must run before this
+// run's github_copilot_seat upserts when state lives in Port. It returns nil
+// (tracking off) when the previous list cannot be read.
+func newSeatTracker(ctx context.Context, cfg config.Config, pcli *portapi.Client, complete bool) *seatTracker {
+	prev, err := loadSeatSnapshot(ctx, cfg, pcli)
+	if err != nil {
+		log.Printf("warn: seat events: previous seats: %v", err)
+		return nil
+	}
+	return &seatTracker{prev: prev, current: map[string]map[string]seatState{}, failed: map[string]map[string]bool{}, complete: complete}
+}
+
+// observe records an org's seats; failed lists the logins whose seat entity
+// was not upserted this run.
+func (t *seatTracker) observe(org string, seats []githubapi.Seat, failed map[string]bool) {
+	if t == nil {
+		return
+	}
+	st := make(map[string]seatState, len(seats))
+	for _, s := range seats {
+		login := s.Login()
+		if login == "" {
+			continue
+		}
+		var assigned string
+		if s.AssignedAt != nil {
+			assigned = s.AssignedAt.UTC().Format(time.RFC3339)
+		}
+		st[login] = seatState{AssignedAt: assigned, PendingCancellation: s.PendingCancellationDate}
+	}
+	t.mu.Lock()
+	t.current[org] = st
+	if len(failed) > 0 {
+		t.failed[org] = failed
+	}
+	t.mu.Unlock()
+}
+
+// flush emits github_copilot_seat_event entities for every change since
+// the previous run and stores the new list.
+func (t *seatTracker) flush(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, recordDate string) {
+	if t == nil {
+		return
+	}
+	prev := t.prev
+	next := seatSnapshot{RecordDate: recordDate, Orgs: map[string]map[string]seatState{}}
+	if !t.complete {
+		for org, seats := range prev.Orgs {
+			next.Orgs[org] = seats
+		}
+	}
+	for org, seats := range t.current {
+		next.Orgs[org] = seats
+	}
+	if prev.RecordDate == "" {
+		log.Printf("seat events: no previous seat list; this run is the baseline")
+	} else {
+		count := 0
+		for org := range next.Orgs {
+			evs := diffSeats(prev.Orgs[org], next.Orgs[org])
+			if cfg.SeatStateSource == "port" && len(t.failed[org]) > 0 {
+				evs = persistedSeatEvents(evs, t.failed[org])
+				log.Printf("warn: seat events %s: %d seat upsert(s) failed; their changes and any removals wait for the next complete run", org, len(t.failed[org]))
+			}
+			for _, ev := range evs {
+				emitSeatEvent(ctx, cfg, hc, pcli, org, ev, prev.RecordDate, recordDate)
+				count++
+			}
+		}
+		if t.complete {
+			for org, seats := range prev.Orgs {
+				if _, ok := next.Orgs[org]; ok {
+					continue
+				}
+				for _, ev := range diffSeats(seats, nil) {
+					emitSeatEvent(ctx, cfg, hc, pcli, org, ev, prev.RecordDate, recordDate)
+					count++
+				}
+			}
+		}
+		log.Printf("seat events: %d change(s) since %s", count, prev.RecordDate)
+	}
+	if err := saveSeatSnapshot(cfg, next); err != nil {
+		log.Printf("warn: seat events: save seats: %v", err)
+	}
+}
+
+type seatEvent struct {
+	login string
+	kind  string
+	state seatState
+}
+
+func diffSeats(prev, cur map[string]seatState) []seatEvent {
+	var out []seatEvent
+	for login, c := range cur {
+		p, existed := prev[login]
+		switch {
+		case !existed:
+			out = append(out, seatEvent{login, seatAdded, c})
+		case p.PendingCancellation == "" && c.PendingCancellation != "":
+			out = append(out, seatEvent{login, seatPendingCancellation, c})
+		case p.PendingCancellation != "" && c.PendingCancellation == "":
+			out = append(out, seatEvent{login, seatReactivated, c})
+		}
+	}
+	for login, p := range prev {
+		if _, ok := cur[login]; !ok {
+			out = append(out, seatEvent{login, seatRemoved, p})
+		}
+	}
+	return out
+}
+
+// persistedSeatEvents drops the events Port-backed state cannot yet see:
+// changes to seats whose upsert failed, which still carry their old state,
+// and removals, which stay in the baseline until an org's seats are all
+// upserted in one run. Both are reported by the next complete run instead.
+func persistedSeatEvents(evs []seatEvent, failed map[string]bool) []seatEvent {
+	var out []seatEvent
+	for _, ev := range evs {
+		if ev.kind == seatRemoved || failed[ev.login] {
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+func emitSeatEvent(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, org string, ev seatEvent, prevDate, recordDate string) {
+	props := map[string]any{
+		"login":           ev.login,
+		"git_hub_org":     org,
+		"event_type":      ev.kind,
+		"detected_at":     recordDate,
+		"previous_run_at": prevDate,
+	}
+	if ev.state.AssignedAt != "" {
+		props["assigned_at"] = ev.state.AssignedAt
+	}
+	if ev.state.PendingCancellation != "" {
+		props["pending_cancellation_date"] = ev.state.PendingCancellation + "T00:00:00Z"
+	}
+	rels := map[string]any{}
+	if cfg.EnableGitHubSeatDetails {
+		rels["seat"] = org + "/" + ev.login
+	}
+	id := org + "/" + ev.login + "@" + ev.kind + "@" + recordDate
+	if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-seat-event", "github_copilot_seat_event",
+		id, props, rels); err != nil {
+		log.Printf("warn: seat event %s: %v", id, err)
+	}
+}
+
+// loadSeatSnapshot reads the previous seat list from SEAT_STATE_FILE, or
+// with SEAT_STATE_SOURCE=port from the github_copilot_seat entities. Seat
+// entities are keyed by org/login and only move forward when upserted, so an
+// org's baseline is every seat recorded since its last snapshot marked
+// seat_details_complete (or, before any marker exists, its latest snapshot).
+func loadSeatSnapshot(ctx context.Context, cfg config.Config, pcli *portapi.Client) (seatSnapshot, error) {
+	snap := seatSnapshot{Orgs: map[string]map[string]seatState{}}
+	if cfg.SeatStateSource == "port" {
+		snaps, err := pcli.ListEntities(ctx, "github_copilot_seats")
+		if err != nil {
+			return snap, err
+		}
+		since := map[string]string{}
+		for _, e := range snaps {
+			org, rd := str(e.Properties["git_hub_org"]), str(e.Properties["record_date"])
+			if complete, _ := e.Properties["seat_details_complete"].(bool); complete && rd > since[org] {
+				since[org] = rd
+			}
+		}
+		ents, err := pcli.ListEntities(ctx, "github_copilot_seat")
+		if err != nil {
+			return snap, err
+		}
+		for org, seats := range seatBaseline(ents, since) {
+			snap.Orgs[org] = seats
+		}
+		for _, e := range ents {
+			org, rd := str(e.Properties["git_hub_org"]), str(e.Properties["record_date"])
+			if snap.Orgs[org] != nil && rd > snap.RecordDate {
+				snap.RecordDate = rd
+			}
+		}
+		return snap, nil
+	}
+	b, err := os.ReadFile(cfg.SeatStateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return snap, nil
+	}
+	if err != nil {
+		return snap, err
+	}
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return snap, fmt.Errorf("decode %s: %w", cfg.SeatStateFile, err)
+	}
+	if snap.Orgs == nil {
+		snap.Orgs = map[string]map[string]seatState{}
+	}
+	return snap, nil
+}
+
+// seatBaseline groups seat entities into org -> login -> state, keeping
+// those recorded on or after the org's date in since, or at the org's latest
+// record_date when it has none.
+func seatBaseline(ents []portapi.Entity, since map[string]string) map[string]map[string]seatState {
+	from := map[string]string{}
+	for _, e := range ents {
+		org, rd := str(e.Properties["git_hub_org"]), str(e.Properties["record_date"])
+		if rd > from[org] {
+			from[org] = rd
+		}
+	}
+	for org, rd := range since {
+		if _, ok := from[org]; ok {
+			from[org] = rd
+		}
+	}
+	out := map[string]map[string]seatState{}
+	for _, e := range ents {
+		org, rd := str(e.Properties["git_hub_org"]), str(e.Properties["record_date"])
+		if org == "" || rd < from[org] {
+			continue
+		}
+		if out[org] == nil {
+			out[org] = map[string]seatState{}
+		}
+		pending := str(e.Properties["pending_cancellation_date"])
+		if len(pending) >= 10 {
+			pending = pending[:10]
+		}
+		out[org][str(e.Properties["login"])] = seatState{
+			AssignedAt:          str(e.Properties["assigned_at"]),
+			PendingCancellation: pending,
+		}
+	}
+	return out
+}
+
+// markSeatDetailsComplete re-upserts an org's seats snapshot with
+// seat_details_complete once every seat entity in it was upserted, which is
+// where SEAT_STATE_SOURCE=port takes the next run's baseline from.
+func markSeatDetailsComplete(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, snapshotID string, props map[string]any, failed map[string]bool) {
+	if !cfg.EnableGitHubSeatEvents || cfg.SeatStateSource != "port" || len(failed) > 0 {
+		return
+	}
+	props["seat_details_complete"] = true
+	if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookSeatsURL, "gh-copilot-seats", "github_copilot_seats",
+		snapshotID, props, nil); err != nil {
+		log.Printf("warn: seat events: mark %s complete: %v", snapshotID, err)
+	}
+}
+
+// saveSeatSnapshot persists the seat list for file-backed state; Port-backed
+// state is the github_copilot_seat entities this run already upserted.
+func saveSeatSnapshot(cfg config.Config, snap seatSnapshot) error {
+	if cfg.SeatStateSource == "port" {
+		return nil
+	}
+	return writeStateFile(cfg.SeatStateFile, snap)
+}
@@ -0,0 +1,115 @@
+package ingest
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+)
+
+func eventKinds(evs []seatEvent) []string {
+	out := make([]string, 0, len(evs))
+	for _, ev := range evs {
+		out = append(out, ev.login+":"+ev.kind)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestDiffSeats(t *testing.T) {
+	tests := []struct {
+		name string
+		prev map[string]seatState
+		cur  map[string]seatState
+		want []string
+	}{
+		{
+			name: "no change",
+			prev: map[string]seatState{"ann": {AssignedAt: "2030-01-01T00:00:00Z"}},
+			cur:  map[string]seatState{"ann": {AssignedAt: "2030-01-01T00:00:00Z"}},
+			want: []string{},
+		},
+		{
+			name: "added and removed",
+			prev: map[string]seatState{"ann": {}},
+			cur:  map[string]seatState{"bob": {}},
+			want: []string{"ann:removed", "bob:added"},
+		},
+		{
+			name: "pending cancellation and reactivation",
+			prev: map[string]seatState{"ann": {}, "bob": {PendingCancellation: "2030-02-01"}},
+			cur:  map[string]seatState{"ann": {PendingCancellation: "2030-02-01"}, "bob": {}},
+			want: []string{"ann:pending_cancellation", "bob:reactivated"},
+		},
+		{
+			name: "still pending is not an event",
+			prev: map[string]seatState{"ann": {PendingCancellation: "2030-02-01"}},
+			cur:  map[string]seatState{"ann": {PendingCancellation: "2030-02-01"}},
+			want: []string{},
+		},
+		{
+			name: "org lost every seat",
+			prev: map[string]seatState{"ann": {}, "bob": {}},
+			want: []string{"ann:removed", "bob:removed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventKinds(diffSeats(tt.prev, tt.cur)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffSeats = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func seatEntity(org, login, recordDate string) portapi.Entity {
+	return portapi.Entity{Properties: map[string]any{"git_hub_org": org, "login": login, "record_date": recordDate}}
+}
+
+func TestSeatBaselineAfterPartialUpsert(t *testing.T) {
+	// Run 1 upserted ann, bob and cat and was marked complete. Run 2
+	// refreshed ann but failed on bob; cat was removed in run 2.
+	ents := []portapi.Entity{
+		seatEntity("acme", "ann", "2030-01-02"),
+		seatEntity("acme", "bob", "2030-01-01"),
+		seatEntity("acme", "cat", "2030-01-01"),
+		seatEntity("acme", "old", "2029-12-01"),
+		seatEntity("beta", "dan", "2030-01-02"),
+		seatEntity("beta", "eve", "2030-01-01"),
+	}
+	got := seatBaseline(ents, map[string]string{"acme": "2030-01-01"})
+	var acme, beta []string
+	for login := range got["acme"] {
+		acme = append(acme, login)
+	}
+	for login := range got["beta"] {
+		beta = append(beta, login)
+	}
+	sort.Strings(acme)
+	if want := []string{"ann", "bob", "cat"}; !reflect.DeepEqual(acme, want) {
+		t.Errorf("acme baseline = %v, want %v (every seat since the complete run)", acme, want)
+	}
+	if want := []string{"dan"}; !reflect.DeepEqual(beta, want) {
+		t.Errorf("beta baseline = %v, want %v (latest snapshot without a marker)", beta, want)
+	}
+
+	// Run 3 sees ann and bob: bob is not re-added and cat is removed once.
+	cur := map[string]seatState{"ann": {}, "bob": {}}
+	if evs := eventKinds(diffSeats(got["acme"], cur)); !reflect.DeepEqual(evs, []string{"cat:removed"}) {
+		t.Errorf("events = %v, want [cat:removed]", evs)
+	}
+}
+
+func TestPersistedSeatEvents(t *testing.T) {
+	evs := []seatEvent{
+		{login: "ann", kind: seatAdded},
+		{login: "bob", kind: seatPendingCancellation},
+		{login: "cat", kind: seatRemoved},
+		{login: "dan", kind: seatAdded},
+	}
+	got := eventKinds(persistedSeatEvents(evs, map[string]bool{"bob": true, "dan": true}))
+	if want := []string{"ann:added"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("persistedSeatEvents = %v, want %v", got, want)
+	}
+}
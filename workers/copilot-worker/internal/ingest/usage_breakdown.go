@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"context"
+	"log"
+
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
+	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
+)
+
+// usageBreakdown upserts one github_copilot_usage_breakdown per leaf of a
+// usage day: editor × model × language for code completions
+// (scopeID/editor/model/language@date), editor × model for IDE and
+// github.com chat (scopeID/feature/editor/model@date). Rows relate back to
+// the day's usage entity (usageID); scope holds the org or enterprise
+// properties of the parent.
+func usageBreakdown(ctx context.Context, cfg config.Config, hc httpx.Doer, pcli *portapi.Client, d githubapi.DayMetrics, usageID, scopeID string, scope map[string]any) int {
+	emitted := 0
+	for _, r := range d.Breakdown() {
+		props := map[string]any{
+			"record_date":     d.Date + "T00:00:00Z",
+			"feature":         r.Feature,
+			"editor":          r.Editor,
+			"model":           r.Model,
+			"is_custom_model": r.IsCustomModel,
+		}
+		var id string
+		if r.Feature == githubapi.FeatureCodeCompletions {
+			l := r.Language
+			props["language"] = l.Name
+			props["engaged_users"] = l.TotalEngagedUsers
+			props["suggestions_count"] = l.TotalCodeSuggestions
+			props["acceptances_count"] = l.TotalCodeAcceptances
+			props["lines_suggested"] = l.TotalCodeLinesSuggested
+			props["lines_accepted"] = l.TotalCodeLinesAccepted
+			id = scopeID + "/" + idSlug(r.Editor) + "/" + idSlug(r.Model) + "/" + idSlug(l.Name) + "@" + d.Date
+		} else {
+			c := r.Chat
+			props["engaged_users"] = c.TotalEngagedUsers
+			props["chats_count"] = c.TotalChats
+			props["chat_insertion_events"] = c.TotalChatInsertionEvents
+			props["chat_copy_events"] = c.TotalChatCopyEvents
+			id = scopeID + "/" + r.Feature + "/" + idSlug(r.Editor) + "/" + idSlug(r.Model) + "@" + d.Date
+		}
+		for k, v := range scope {
+			props[k] = v
+		}
+		if err := upsertEntity(ctx, cfg, hc, pcli, cfg.WebhookUsageURL, "gh-copilot-usage-breakdown", "github_copilot_usage_breakdown",
+			id, props, map[string]any{"usage": usageID}); err != nil {
+			log.Printf("warn: gh usage breakdown %s: %v", id, err)
+			continue
+		}
+		emitted++
+	}
+	return emitted
+}
@@ -1,20 +1,24 @@
-// Ingest GitHub Copilot seats and Microsoft 365 Copilot usage into Port.
+// Ingest GitHub Copilot seats + metrics and Microsoft 365 Copilot usage into Port.
 // - Uses Port Webhooks (recommended) or Port Entities API directly.
 // - Safe by default: timeouts, retries with backoff, and no secret logging.
 // - PII: hashes UPN if de-identified reports hide user names.
 //
 // Build: go build -o copilot-worker ./...
+// Run: ./copilot-worker (ingest) or ./copilot-worker reclaim (dormant seats)
 // Env: see copilot.config.example.env
 package main
 
 import (
 	"context"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/httpx"
 	"github.com/port-labs/port-ai-ops-toolkit/pkg/common/portapi"
 	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/config"
+	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/githubapi"
 	"github.com/port-labs/port-ai-ops-toolkit/workers/copilot-worker/internal/ingest"
 )
 
@@ -28,17 +32,25 @@ func main() {
 		if cfg.EnableGitHub && cfg.WebhookSeatsURL == "" {
 			log.Fatal("USE_PORT_WEBHOOK=true but PORT_WEBHOOK_SEATS_URL is missing while INGEST_GITHUB=true")
 		}
+		// Deployments that predate the usage webhook only ingest seats; keep
+		// them running rather than failing on the new metrics default.
+		if (cfg.EnableGitHubMetrics || cfg.EnableGitHubTeams || cfg.EnableGitHubPremiumUsage) && cfg.WebhookUsageURL == "" {
+			log.Printf("warn: USE_PORT_WEBHOOK=true but PORT_WEBHOOK_USAGE_URL is missing; skipping GitHub usage, team and premium usage ingest")
+			cfg.EnableGitHubMetrics, cfg.EnableGitHubTeams, cfg.EnableGitHubPremiumUsage = false, false, false
+			cfg.EnableGitHubUsageBreakdown = false
+		}
 		if cfg.EnableM365 && (cfg.WebhookM365SumURL == "" || cfg.WebhookM365UsrURL == "") {
 			log.Fatal("USE_PORT_WEBHOOK=true but M365 webhook URLs are missing while INGEST_M365=true")
 		}
-	} else {
-		if cfg.PortAccessToken == "" && (cfg.PortClientID == "" || cfg.PortClientSecret == "") {
-			log.Fatal("Provide PORT_ACCESS_TOKEN or PORT_CLIENT_ID/PORT_CLIENT_SECRET")
-		}
+	}
+	// Port-backed seat state is read through the Entities API even in webhook mode.
+	needPortAPI := !cfg.UseWebhook || (cfg.EnableGitHubSeatEvents && cfg.SeatStateSource == "port")
+	if needPortAPI && cfg.PortAccessToken == "" && (cfg.PortClientID == "" || cfg.PortClientSecret == "") {
+		log.Fatal("Provide PORT_ACCESS_TOKEN or PORT_CLIENT_ID/PORT_CLIENT_SECRET")
 	}
 
 	hc := httpx.New()
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.RunTimeout)
 	defer cancel()
 
 	// Create Port client only if needed
@@ -46,7 +58,7 @@ func main() {
 		pcli *portapi.Client
 		err  error
 	)
-	if !cfg.UseWebhook {
+	if needPortAPI {
 		pcli, err = portapi.NewClient(ctx, hc, cfg.PortRegion, cfg.PortAccessToken, cfg.PortClientID, cfg.PortClientSecret)
 		if err != nil {
 			log.Fatalf("port client: %v", err)
@@ -55,8 +67,38 @@ func main() {
 
 	recordDate := time.Now().UTC().Format(time.RFC3339)
 
+	if len(os.Args) > 1 && os.Args[1] == "reclaim" {
+		if !cfg.EnableGitHub || len(cfg.GitHubOrgs) == 0 {
+			log.Fatal("reclaim needs INGEST_GITHUB=true and GITHUB_ORG(S)")
+		}
+		ghc, rl := gitHubDoer(cfg, hc)
+		ingest.Reclaim(ctx, cfg, ghc, pcli, recordDate)
+		log.Printf("gh rate limit: %s", rl.Report())
+		return
+	}
+
 	if cfg.EnableGitHub {
-		ingest.GitHubSeats(ctx, cfg, hc, pcli, recordDate)
+		ghc, rl := gitHubDoer(cfg, hc)
+		ingest.GitHubSeats(ctx, cfg, ghc, pcli, recordDate)
+		if cfg.EnableGitHubOrgSettings && len(cfg.GitHubOrgs) > 0 {
+			ingest.GitHubOrgSettings(ctx, cfg, ghc, pcli, recordDate)
+		}
+		if cfg.EnableGitHubAuditLog && len(cfg.GitHubOrgs) > 0 {
+			ingest.GitHubAuditLog(ctx, cfg, ghc, pcli)
+		}
+		if cfg.EnableGitHubMetrics && cfg.GitHubEnterprise != "" {
+			ingest.GitHubEnterpriseUsage(ctx, cfg, ghc, pcli)
+		}
+		if cfg.EnableGitHubMetrics && len(cfg.GitHubOrgs) > 0 {
+			ingest.GitHubUsage(ctx, cfg, ghc, pcli)
+		}
+		if cfg.EnableGitHubTeams && len(cfg.GitHubOrgs) > 0 {
+			ingest.GitHubTeamUsage(ctx, cfg, ghc, pcli)
+		}
+		if cfg.EnableGitHubPremiumUsage {
+			ingest.GitHubPremiumUsage(ctx, cfg, ghc, pcli)
+		}
+		log.Printf("gh rate limit: %s", rl.Report())
 	}
 
 	if cfg.EnableM365 {
@@ -65,3 +107,35 @@ func main() {
 
 	log.Println("ingestion completed")
 }
+
+// gitHubDoer wraps hc with GitHub rate limit throttling and, with
+// GITHUB_APP_ID set, App installation token auth on top.
+func gitHubDoer(cfg config.Config, hc httpx.Doer) (httpx.Doer, *githubapi.RateLimiter) {
+	rl, err := githubapi.NewRateLimiter(hc, cfg.GitHubAPIBase, cfg.GitHubRateLimitReserve)
+	if err != nil {
+		log.Fatalf("github rate limiter: %v", err)
+	}
+	if cfg.GitHubAppID == "" {
+		return rl, rl
+	}
+	key, err := githubapi.ParsePrivateKey([]byte(cfg.GitHubAppPrivateKey))
+	if err != nil {
+		log.Fatalf("github app: %v", err)
+	}
+	installs := map[string]string{}
+	for _, org := range cfg.GitHubOrgs {
+		if org.InstallationID != "" {
+			installs[strings.ToLower(org.Name)] = org.InstallationID
+		}
+	}
+	d, err := githubapi.NewAppDoer(rl, cfg.GitHubAPIBase, cfg.GitHubAPIVer, githubapi.App{
+		ID:             cfg.GitHubAppID,
+		Key:            key,
+		InstallationID: cfg.GitHubAppInstallationID,
+		Installations:  installs,
+	})
+	if err != nil {
+		log.Fatalf("github app: %v", err)
+	}
+	return d, rl
+}